@@ -13,10 +13,10 @@ func Test_Client(t *testing.T) {
 
 	paths := []string{
 		"/user/1",       // {"code":200,"msg":"","data":{"id":1,"name":"Alice","teamId":1}}
-		"/user/3",       // {"code":400,"msg":"user not found: 3","data":null}
+		"/user/3",       // {"code":404,"msg":"user not found: 3","data":null}
 		"/user/1/team",  // {"code":200,"msg":"","data":{"id":1,"name":"Alice","team":{"id":3,"name":"Apple"}}}
 		"/team/3",       // {"code":200,"msg":"","data":{"id":3,"name":"Apple"}}
-		"/team/5",       // {"code":400,"msg":"team not found: 5","data":null}
+		"/team/5",       // {"code":404,"msg":"team not found: 5","data":null}
 		"/team/3/users", // {"code":200,"msg":"","data":{"users":[{"id":1,"name":"Alice","teamId":3}]}}
 		"/team/5/users", // {"code":200,"msg":"","data":{"Users":null}}
 	}
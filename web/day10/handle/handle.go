@@ -1,28 +1,39 @@
 package handle
 
 import (
+	"errors"
 	"reflect"
 
 	"github.com/gin-gonic/gin"
 )
 
 type Response struct {
-	Code int    `json:"code"` // 业务代码，200 表示 OK，其他表示错误
-	Msg  string `json:"msg"`  // 错误消息
-	Data any    `json:"data"` // 返回的数据
+	Code int    `json:"code" xml:"code" yaml:"code"` // 业务代码，200 表示 OK，其他表示错误
+	Msg  string `json:"msg" xml:"msg" yaml:"msg"`    // 错误消息
+	Data any    `json:"data" xml:"data" yaml:"data"` // 返回的数据
+
+	RequestId string `json:"requestId,omitempty" xml:"requestId,omitempty" yaml:"requestId,omitempty"` // 请求 id，注册 RequestID 中间件时返回
 }
 
 const (
-	CodeOK         = 200 // 业务正常
-	CodeBadRequest = 400 // 请求参数异常
+	CodeOK                    = 200 // 业务正常
+	CodeBadRequest            = 400 // 请求参数异常
+	CodeForbidden             = 403 // 无权访问
+	CodeNotFound              = 404 // 资源不存在
+	CodeNotAcceptable         = 406 // 无法以客户端接受的格式返回
+	CodeConflict              = 409 // 资源冲突
+	CodeRequestEntityTooLarge = 413 // 请求体过大
+	CodeUnsupportedMediaType  = 415 // 不支持的请求体格式
+	CodeInternal              = 500 // 服务内部错误
+	CodeGatewayTimeout        = 504 // 处理超时
 )
 
-func Handle(decode DecodeFunc) gin.HandlerFunc {
-	return decode.Handler()
+func Handle(decode DecodeFunc, opts ...Option) gin.HandlerFunc {
+	return decode.Handler(opts...)
 }
 
 // ObjectHandler 通过结构体（对象）注册路由
-// 这个结构体的所有方法都必须为 `ReqResFunc` 格式，否则会触发 panic
+// 这个结构体的所有方法都必须为 `ReqResFunc` 格式，否则会触发 panic，需要返回错误时使用 TryObjectHandler
 //
 // 缺陷：无法为 handles[i] 绑定 `path` 和 `method`
 //
@@ -30,12 +41,21 @@ func Handle(decode DecodeFunc) gin.HandlerFunc {
 //
 // 1. 在请求参数 `XXXReq` 里写 tag，
 // 参考：[规范参数结构](https://goframe.org/pages/viewpage.action?pageId=116004922)
+// 实现见 `RegisterObject`
 //
 // 2. 要求函数名格式为 请求方法+请求路径，如 `GetHelloWorld` 对应 `GET: /hello/world`，
 // 参考：[examples/mvc/hello-world/main.go](https://github.com/iris-contrib/examples/blob/master/mvc/hello-world/main.go)
-//
-// 实现见 `handle_test.go/TestObjectHandler`
-func ObjectHandler(object any, f func(fn *ReqResFunc, methodName string)) {
+// 实现见 `RegisterByName`
+func ObjectHandler(object any, f func(fn *ReqResFunc, methodName string), opts ...Option) {
+	if err := TryObjectHandler(object, f, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// TryObjectHandler 与 ObjectHandler 相同，但不会触发 panic：
+// 跳过签名不符合要求的方法，并返回所有方法的 *SignatureError，Method 为 结构体名.方法名；
+// opts 中只有 WithNaming 与 WithInterceptors 会生效
+func TryObjectHandler(object any, f func(fn *ReqResFunc, methodName string), opts ...Option) error {
 	v := reflect.ValueOf(object)
 
 	// 如果是结构体, 那么获取这个结构体的指针, 从而遍历到他的所有方法
@@ -46,12 +66,20 @@ func ObjectHandler(object any, f func(fn *ReqResFunc, methodName string)) {
 	}
 
 	if v.Kind() != reflect.Pointer {
-		panic("the kind of object must be Struct or *Struct")
+		return &SignatureError{Method: objectName(object), Type: reflect.TypeOf(object), Rule: RuleObject, Msg: "the kind of object must be Struct or *Struct"}
 	}
 
+	o := newOptions(opts)
+
+	var errs []error
 	t := v.Type()
 	for i := 0; i < t.NumMethod(); i++ {
-		fn := NewReqResFunc(v.Method(i).Interface())
+		fn, err := newReqResFunc(v.Method(i), objectName(object)+"."+t.Method(i).Name, o)
+		if err != nil {
+			errs = append(errs, err...)
+			continue
+		}
 		f(fn, t.Method(i).Name)
 	}
+	return errors.Join(errs...)
 }
@@ -0,0 +1,77 @@
+package handle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	requestKey    struct{}
+	ginContextKey struct{}
+)
+
+// Request 返回 ctx 所属的 HTTP 请求，不是 handle 传递给处理函数的 ctx 时返回 nil
+func Request(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(requestKey{}).(*http.Request)
+	return r
+}
+
+// GinContext 返回 ctx 所属的 *gin.Context，不是 handle 传递给处理函数的 ctx 时返回 nil；
+// gin 会复用 *gin.Context，它只在处理函数返回前有效，不能在处理函数启动的 goroutine 中使用
+func GinContext(ctx context.Context) *gin.Context {
+	if c, ok := ctx.(*gin.Context); ok {
+		return c
+	}
+	c, _ := ctx.Value(ginContextKey{}).(*gin.Context)
+	return c
+}
+
+// WithTimeout 设置处理函数的超时时间，超时后 ctx 被取消，处理函数返回 context.DeadlineExceeded 时响应 504；
+// 也可以在 meta 标签中声明：`timeout:"3s"`
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) { o.timeout = timeout }
+}
+
+// requestValues 返回携带请求与 *gin.Context 的 ctx，生命周期与请求相同
+func requestValues(c *gin.Context) context.Context {
+	ctx := context.WithValue(c.Request.Context(), requestKey{}, c.Request)
+	return context.WithValue(ctx, ginContextKey{}, c)
+}
+
+// requestContext 返回传递给处理函数的 ctx，处理函数返回后需要调用 cancel
+func (o *options) requestContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	if o.timeout > 0 {
+		return context.WithTimeout(requestValues(c), o.timeout)
+	}
+	return context.WithCancel(requestValues(c))
+}
+
+// statusClientClosedRequest 客户端在响应之前断开连接，沿用 nginx 的约定，只用于日志
+const statusClientClosedRequest = 499
+
+var errGatewayTimeout = NewError(CodeGatewayTimeout, http.StatusGatewayTimeout, "")
+
+// contextError 处理函数因 ctx 结束而返回错误时：超时转换为 504，客户端断开连接时返回 aborted 为 true，无需响应
+func contextError(c *gin.Context, ctx context.Context, err error) (_ error, aborted bool) {
+	switch {
+	case c.Request.Context().Err() != nil:
+		return err, true
+	case errors.Is(err, context.DeadlineExceeded) && errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return errGatewayTimeout.Wrap(err), false
+	}
+	return err, false
+}
+
+// parseTimeout 解析 meta 标签中的 timeout
+func parseTimeout(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,151 @@
+package handle_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WaitReq struct{}
+
+type WaitRes struct {
+	Method string `json:"method"`
+}
+
+// wait 模拟耗时的服务，直到 ctx 结束
+func wait(started chan<- struct{}, done chan<- error) func(ctx context.Context, req *WaitReq) (*WaitRes, error) {
+	return func(ctx context.Context, req *WaitReq) (*WaitRes, error) {
+		close(started)
+		<-ctx.Done()
+		done <- ctx.Err()
+		return nil, ctx.Err()
+	}
+}
+
+func TestContextClientDisconnect(t *testing.T) {
+	started, done := make(chan struct{}), make(chan error, 1)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/wait", handle.NewReqResFunc(wait(started, done)).DecodeFunc().Handler())
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/wait", nil)
+	go func() {
+		<-started
+		cancel() // 客户端断开连接
+	}()
+	if _, err := http.DefaultClient.Do(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("client got %v, want context.Canceled", err)
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("service got %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("cancellation did not reach the service")
+	}
+}
+
+func TestContextTimeout(t *testing.T) {
+	started, done := make(chan struct{}), make(chan error, 1)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/wait", handle.NewReqResFunc(wait(started, done)).DecodeFunc().Handler(handle.WithTimeout(20*time.Millisecond)))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/wait", nil))
+	if want := `{"code":504,"msg":"Gateway Timeout","data":null}`; w.Code != http.StatusGatewayTimeout || w.Body.String() != want {
+		t.Errorf("got %d %s, want 504 %s", w.Code, w.Body, want)
+	}
+	if err := <-done; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("service got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestContextAccessors(t *testing.T) {
+	if handle.Request(context.Background()) != nil || handle.GinContext(context.Background()) != nil {
+		t.Error("accessors of a background context should return nil")
+	}
+
+	var served context.Context
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/accessors", handle.NewReqResFunc(func(ctx context.Context, req *WaitReq) (*WaitRes, error) {
+		served = ctx
+		if _, ok := ctx.(*gin.Context); ok {
+			t.Error("ctx should not be *gin.Context")
+		}
+		if c := handle.GinContext(ctx); c == nil || c.FullPath() != "/accessors" {
+			t.Errorf("GinContext = %v", c)
+		}
+		return &WaitRes{Method: handle.Request(ctx).Method}, nil
+	}).DecodeFunc().Handler())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/accessors", nil))
+	if want := `{"code":200,"msg":"","data":{"method":"POST"}}`; w.Body.String() != want {
+		t.Errorf("got %s, want %s", w.Body, want)
+	}
+	if served == nil || served.Err() == nil {
+		t.Error("ctx should be canceled after the handler returns")
+	}
+}
+
+type (
+	TimeoutReq struct {
+		meta struct{} `method:"GET" path:"/timeout" timeout:"20ms"`
+	}
+	TimeoutRes struct{}
+)
+
+type Timeouts struct{}
+
+func (Timeouts) GetTimeout(ctx context.Context, req *TimeoutReq) (*TimeoutRes, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestContextMetaTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fn := Timeouts{}.GetTimeout
+	for name, register := range map[string]func(r *gin.Engine) error{
+		"RegisterObject": func(r *gin.Engine) error { return handle.RegisterObject(r, Timeouts{}) },
+		"RegisterByName": func(r *gin.Engine) error { return handle.RegisterByName(r, Timeouts{}, handle.NameOption{}) },
+		"ReqResFunc": func(r *gin.Engine) error {
+			r.GET("/timeout", handle.NewReqResFunc(fn).Handler())
+			return nil
+		},
+		"Typed": func(r *gin.Engine) error {
+			r.GET("/timeout", handle.Typed(fn).Handler())
+			return nil
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := gin.New()
+			if err := register(r); err != nil {
+				t.Fatal(err)
+			}
+			// meta 标签的 timeout 不依赖注册方式，没有生效时处理函数等到请求的 ctx 取消
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/timeout", nil).WithContext(ctx))
+			if w.Code != http.StatusGatewayTimeout {
+				t.Errorf("got %d %s, want 504", w.Code, w.Body)
+			}
+		})
+	}
+}
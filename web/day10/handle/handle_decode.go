@@ -3,6 +3,7 @@ package handle
 import (
 	"context"
 	"net/http"
+	"reflect"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,25 +16,106 @@ type DecodeFunc func(
 	err error, // 错误处理
 )
 
-func (f DecodeFunc) Handler() gin.HandlerFunc {
+// Handler 返回 gin.HandlerFunc，opts 用于配置返回值的包装与编码等
+//
+// 传递给处理函数的 ctx 派生自请求的 context.Context，而不是 *gin.Context：
+//
+//  1. 客户端断开连接时取消，服务层可以通过 ctx.Done() 停止处理
+//  2. 设置了 WithTimeout 或 meta 标签 `timeout:"3s"` 时携带截止时间，超时后返回 504
+//  3. 携带中间件写入请求 context 的值，如 RequestID 写入的请求 id
+//  4. 处理函数返回后取消，服务层启动的 goroutine 不会继续占用请求的资源
+//
+// 服务层应只依赖 ctx，确实需要原始请求时通过 Request 或 GinContext 读取
+//
+// 迁移：之前 ctx 就是 *gin.Context，以下用法需要修改：
+//
+//	ctx.(*gin.Context)   → handle.GinContext(ctx)，或将处理函数的第一个参数声明为 *gin.Context
+//	ctx.Value("user")    → handle.GinContext(ctx).Get("user")，读取中间件通过 c.Set 写入的值；
+//	                       更好的做法是中间件通过 c.Request.WithContext 写入类型化的 key
+//
+// DecodeFunc 不携带返回参数的类型，ReqResFunc 与 TypedFunc 应使用各自的 Handler，以便在构造时排除无法编码返回值的格式
+func (f DecodeFunc) Handler(opts ...Option) gin.HandlerFunc {
+	o := newOptions(opts)
+	formats := o.encodable()
+	// 事件流与 Responder 自行写入响应，不受 Accept 限制
+	bypass := !o.staticRes() || (o.res != nil && (isResponder(o.res) || isEventSource(o.res)))
+	name := funcName(reflect.ValueOf(f))
+
 	return func(c *gin.Context) {
-		data, err := f(c, func(point any) error {
-			// 解析动态路由
-			if len(c.Params) > 0 {
-				err := c.ShouldBindUri(point)
-				if err != nil {
-					return err
+		// Accept 无法协商时：返回参数的类型已知且不能绕过协商时，在解析请求之前返回 406；
+		// 否则等处理函数返回后再确认，事件流与 Responder 照常返回，其他返回 406
+		encode := o.encoder
+		if encode == nil {
+			if accepted := negotiate(c.GetHeader("Accept"), formats); len(accepted) > 0 {
+				encode = negotiatedEncoder(accepted, o.envelope)
+			} else if !bypass {
+				notAcceptable(c, o.envelope)
+				return
+			} else {
+				encode = func(c *gin.Context, status int, body any) {
+					if status < http.StatusBadRequest {
+						notAcceptable(c, o.envelope)
+						return
+					}
+					JSONEncoder(c, status, body)
 				}
 			}
+		}
+
+		o.limitBody(c)
+		defer cleanupUploads(c)
 
-			// 实现反序列化
-			return c.ShouldBind(point)
-		})
+		ctx, cancel := o.requestContext(c)
+		defer cancel()
+
+		data, err := f.call(ctx, name, func(point any) error { return o.decode(c, point) })
 		if err != nil {
-			c.JSON(http.StatusOK, Response{Code: CodeBadRequest, Msg: err.Error(), Data: nil})
+			var aborted bool
+			if err, aborted = contextError(c, ctx, err); aborted {
+				c.AbortWithStatus(statusClientClosedRequest) // 客户端已经断开连接，无需响应
+				return
+			}
+		}
+		if err == nil {
+			if r, ok := asResponder(data); ok {
+				err = r.Respond(c.Writer, c.Request)
+				if err == nil {
+					return
+				}
+				if c.Writer.Written() {
+					_ = c.Error(err)
+					return
+				}
+			} else if ch, errc, ok := eventSource(c.Request.Context(), data); ok {
+				serveEvents(c, o, ch, errc)
+				return
+			}
+		}
+		if err != nil {
+			status, body := o.failure(c, err)
+			encode(c, status, body)
 			return
 		}
 
-		c.JSON(http.StatusOK, Response{Code: CodeOK, Msg: "", Data: data})
+		out := writeOut(c, data)
+		status, body := o.envelope.Success(c, data)
+		if out != 0 {
+			status = out
+		}
+		encode(c, status, body)
+	}
+}
+
+// withRes 记录返回参数的静态类型，由 Func 的 Handler 追加在 opts 之后
+func withRes(res reflect.Type) Option {
+	return func(o *options) { o.res, o.resKnown = res, true }
+}
+
+// funcHandler 返回 Func 的 handler，依次应用 opts 与 meta 标签声明的选项，构造时根据返回参数的类型确定可用的编码格式
+func funcHandler(f Func, opts []Option) gin.HandlerFunc {
+	opts = opts[:len(opts):len(opts)]
+	if m, ok := f.(interface{ metaOptions() []Option }); ok {
+		opts = append(opts, m.metaOptions()...)
 	}
+	return f.DecodeFunc().Handler(append(opts, withRes(f.Res()))...)
 }
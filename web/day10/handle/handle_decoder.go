@@ -0,0 +1,240 @@
+package handle
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/ugorji/go/codec"
+)
+
+// Decoder 将请求体反序列化到 point
+type Decoder func(c *gin.Context, point any) error
+
+// bindingDecoder 将 gin 的 binding.Binding 转换为 Decoder
+func bindingDecoder(b binding.Binding) Decoder {
+	return func(c *gin.Context, point any) error { return c.ShouldBindWith(point, b) }
+}
+
+// CBORDecoder 以 CBOR 格式反序列化请求体，并使用 gin 的 binding 标签校验
+func CBORDecoder(c *gin.Context, point any) error {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	if err := codec.NewDecoderBytes(body, new(codec.CborHandle)).Decode(point); err != nil {
+		return err
+	}
+	if binding.Validator == nil {
+		return nil
+	}
+	return binding.Validator.ValidateStruct(point)
+}
+
+// formDecoder 解析 query 与表单，在 gin 的 binding 之上：
+//
+//  1. 声明了 in 标签的字段（body 除外）不会从表单中读取，由 bindSources 按来源读取
+//  2. 带 form 标签的切片与 map 支持 name[key]=value，以及通过 split 标签声明的分隔符，见 setValues
+//  3. 带 form 标签的 Upload 读取 multipart 表单中的文件，见 Upload
+func formDecoder(b binding.Binding) Decoder {
+	return func(c *gin.Context, point any) error {
+		t := reflect.TypeOf(point)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return c.ShouldBindWith(point, b)
+		}
+		fields, err := sourceFields(t)
+		if err != nil {
+			return err
+		}
+
+		req := c.Request
+		if b == binding.FormMultipart {
+			err = req.ParseMultipartForm(defaultMultipartMemory)
+		} else {
+			err = req.ParseForm()
+		}
+		if err != nil {
+			return err
+		}
+
+		// gin 只读取 req.Form 与 req.MultipartForm，临时替换为去掉这些字段的副本
+		form := req.Form
+		req.Form = omitFields(form, fields)
+		defer func() { req.Form = form }()
+		var files map[string][]*multipart.FileHeader
+		if req.MultipartForm != nil {
+			value := req.MultipartForm.Value
+			files = req.MultipartForm.File
+			req.MultipartForm.Value = omitFields(value, fields)
+			req.MultipartForm.File = omitUploads(files, fields)
+			defer func() { req.MultipartForm.Value, req.MultipartForm.File = value, files }()
+		}
+		if err := c.ShouldBindWith(point, b); err != nil {
+			return err
+		}
+
+		v := reflect.ValueOf(point).Elem()
+		for v.Kind() == reflect.Pointer {
+			v = v.Elem()
+		}
+		for _, sf := range fields {
+			if sf.upload {
+				bindFiles(c, v, sf, files)
+				continue
+			}
+			if !sf.implicit {
+				continue
+			}
+			values, ok := formValues(form, sf.name)
+			if !ok {
+				continue
+			}
+			if err := setValues(v.FieldByIndex(sf.index), values, sf.layout, sf.split); err != nil {
+				return fmt.Errorf("invalid parameter %q: %w", sf.name, err)
+			}
+		}
+		return nil
+	}
+}
+
+// defaultMultipartMemory 与 gin 一致，解析 multipart 表单时保存在内存中的最大字节数
+const defaultMultipartMemory = 32 << 20
+
+// omitFields 返回去掉 fields 对应参数（含 name[key]）的副本
+func omitFields(form url.Values, fields []sourceField) url.Values {
+	omitted := make(url.Values, len(form))
+	for key, values := range form {
+		omitted[key] = values
+	}
+	for _, sf := range fields {
+		if sf.in == InBody {
+			continue
+		}
+		for key := range omitted {
+			if key == sf.form || strings.HasPrefix(key, sf.form+"[") {
+				delete(omitted, key)
+			}
+		}
+	}
+	return omitted
+}
+
+// omitUploads 返回去掉 Upload 字段对应文件的副本，gin 无法为 Upload 赋值
+func omitUploads(files map[string][]*multipart.FileHeader, fields []sourceField) map[string][]*multipart.FileHeader {
+	omitted := make(map[string][]*multipart.FileHeader, len(files))
+	for key, fhs := range files {
+		omitted[key] = fhs
+	}
+	for _, sf := range fields {
+		if sf.upload {
+			delete(omitted, sf.name)
+		}
+	}
+	return omitted
+}
+
+// defaultDecoders 默认支持的请求体格式，key 为 Content-Type 中的 MIME 类型
+var defaultDecoders = map[string]Decoder{
+	binding.MIMEJSON:              bindingDecoder(binding.JSON),
+	binding.MIMEPOSTForm:          formDecoder(binding.Form),
+	binding.MIMEMultipartPOSTForm: formDecoder(binding.FormMultipart),
+	binding.MIMEXML:               bindingDecoder(binding.XML),
+	binding.MIMEXML2:              bindingDecoder(binding.XML),
+	binding.MIMEYAML:              bindingDecoder(binding.YAML),
+	"application/yaml":            bindingDecoder(binding.YAML),
+	binding.MIMEMSGPACK:           bindingDecoder(binding.MsgPack),
+	binding.MIMEMSGPACK2:          bindingDecoder(binding.MsgPack),
+	"application/cbor":            CBORDecoder,
+	binding.MIMEPROTOBUF:          bindingDecoder(binding.ProtoBuf),
+}
+
+// WithDecoder 注册 MIME 类型 mimeType 的请求体解码器，会覆盖默认的解码器
+func WithDecoder(mimeType string, decoder Decoder) Option {
+	return func(o *options) {
+		decoders := make(map[string]Decoder, len(o.decoders)+1)
+		for k, v := range o.decoders {
+			decoders[k] = v
+		}
+		decoders[mimeType] = decoder
+		o.decoders = decoders
+	}
+}
+
+var errUnsupportedMediaType = NewError(CodeUnsupportedMediaType, http.StatusUnsupportedMediaType, "")
+
+// decode 反序列化请求参数：动态路由、请求体（或 query）、in 标签声明的来源、默认值，最后校验请求参数
+func (o *options) decode(c *gin.Context, point any) error {
+	// 解析动态路由
+	if len(c.Params) > 0 {
+		err := c.ShouldBindUri(point)
+		if err != nil {
+			return badRequest(err)
+		}
+	}
+
+	// 实现反序列化
+	decoder, hasBody, err := o.decoder(c)
+	if err != nil {
+		return err
+	}
+	if err := decoder(c, point); err != nil {
+		if e, ok := tooLarge(err); ok {
+			return e
+		}
+		return badRequest(err)
+	}
+	if err := o.checkUploadTypes(c); err != nil {
+		return err
+	}
+
+	// 按 in 标签读取请求头、Cookie、query、动态路由
+	if err := bindSources(c, point, hasBody); err != nil {
+		return err
+	}
+
+	return validateRequest(point)
+}
+
+// validateRequest 设置默认值并校验请求参数
+func validateRequest(point any) error {
+	if err := applyDefaults(point); err != nil {
+		return err
+	}
+	if err := Validate(point); err != nil {
+		var errs ValidationErrors
+		if errors.As(err, &errs) {
+			return validationError(errs)
+		}
+		return err
+	}
+	return nil
+}
+
+// decoder 根据请求方法与 Content-Type 选择解码器，GET 请求或没有 Content-Type 时解析 query 与表单，
+// 第二个返回值表示是否解析了请求体
+func (o *options) decoder(c *gin.Context) (Decoder, bool, error) {
+	contentType := c.ContentType()
+	if c.Request.Method == http.MethodGet || contentType == "" {
+		return formDecoder(binding.Form), false, nil
+	}
+
+	mimeType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mimeType = contentType
+	}
+	if decoder, ok := o.decoders[mimeType]; ok {
+		return decoder, true, nil
+	}
+	return nil, false, errUnsupportedMediaType.Wrap(fmt.Errorf("unsupported Content-Type %q", contentType))
+}
@@ -0,0 +1,116 @@
+package handle_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type (
+	ProfileReq struct {
+		Name string `json:"name" form:"name" xml:"name" yaml:"name"`
+		Age  int    `json:"age" form:"age" xml:"age" yaml:"age"`
+	}
+	ProfileRes = ProfileReq
+)
+
+func Profile(ctx context.Context, req *ProfileReq) (res *ProfileRes, err error) {
+	return req, nil
+}
+
+func Upper(ctx context.Context, req *wrapperspb.StringValue) (res *wrapperspb.StringValue, err error) {
+	return wrapperspb.String(strings.ToUpper(req.GetValue())), nil
+}
+
+func TestDecoder(t *testing.T) {
+	encode := func(h codec.Handle) string {
+		var b []byte
+		_ = codec.NewEncoderBytes(&b, h).Encode(map[string]any{"name": "gee", "age": 3})
+		return string(b)
+	}
+
+	var multipartBody bytes.Buffer
+	mw := multipart.NewWriter(&multipartBody)
+	_ = mw.WriteField("name", "gee")
+	_ = mw.WriteField("age", "3")
+	_ = mw.Close()
+
+	// 自定义解码器：name,age
+	csvDecoder := func(c *gin.Context, point any) error {
+		record, err := csv.NewReader(c.Request.Body).Read()
+		if err != nil {
+			return err
+		}
+		req := point.(*ProfileReq)
+		req.Name = record[0]
+		req.Age, err = strconv.Atoi(record[1])
+		return err
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/profile", handle.Typed(Profile).Handler(handle.WithDecoder("text/csv", csvDecoder)))
+	r.GET("/profile", handle.Typed(Profile).Handler())
+
+	want := `{"code":200,"msg":"","data":{"name":"gee","age":3}}`
+	tests := []struct {
+		method, contentType, body string
+		status                    int
+		want                      string
+	}{
+		{"POST", "application/json", `{"name":"gee","age":3}`, 200, want},
+		{"POST", "application/json; charset=utf-8", `{"name":"gee","age":3}`, 200, want},
+		{"POST", "application/x-www-form-urlencoded", `name=gee&age=3`, 200, want},
+		{"POST", mw.FormDataContentType(), multipartBody.String(), 200, want},
+		{"POST", "application/xml", `<ProfileReq><name>gee</name><age>3</age></ProfileReq>`, 200, want},
+		{"POST", "application/yaml", "name: gee\nage: 3\n", 200, want},
+		{"POST", "application/msgpack", encode(new(codec.MsgpackHandle)), 200, want},
+		{"POST", "application/cbor", encode(new(codec.CborHandle)), 200, want},
+		{"POST", "text/csv", "gee,3\n", 200, want},
+		{"POST", "text/plain", "gee", 415, `{"code":415,"msg":"Unsupported Media Type","data":null}`},
+		{"GET", "", "", 200, want},
+	}
+	for _, tt := range tests {
+		target := "/profile"
+		if tt.method == http.MethodGet {
+			target += "?name=gee&age=3"
+		}
+		req := httptest.NewRequest(tt.method, target, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status || w.Body.String() != tt.want {
+			t.Errorf("%s %s: got %d %s, want %d %s", tt.method, tt.contentType, w.Code, w.Body, tt.status, tt.want)
+		}
+	}
+
+	t.Run("protobuf", func(t *testing.T) {
+		r.POST("/upper", handle.Typed(Upper).Handler(handle.WithFormats(handle.FormatProtobuf)))
+
+		body, _ := proto.Marshal(wrapperspb.String("gee"))
+		req := httptest.NewRequest(http.MethodPost, "/upper", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		req.Header.Set("Accept", "application/x-protobuf")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var msg wrapperspb.StringValue
+		if err := proto.Unmarshal(w.Body.Bytes(), &msg); err != nil || msg.GetValue() != "GEE" {
+			t.Errorf("got %q, %v", msg.GetValue(), err)
+		}
+	})
+}
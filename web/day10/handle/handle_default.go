@@ -0,0 +1,98 @@
+package handle
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// defaultField 声明了 default 标签的字段
+type defaultField struct {
+	index  []int  // 字段的索引，匿名字段会展开
+	name   string // 字段名，用于错误消息
+	value  string // 默认值
+	layout string // time.Time 的格式，取自 time_format 标签
+}
+
+var defaultsCache sync.Map // map[reflect.Type][]defaultField
+
+// defaultFields 解析并缓存结构体中声明了 default 标签的字段，默认值无法转换为字段的类型时返回错误；
+// 构造处理函数时调用，使不合法的默认值在注册时报告，而不是在每次请求时返回 500
+func defaultFields(t reflect.Type) ([]defaultField, error) {
+	if cached, ok := defaultsCache.Load(t); ok {
+		return cached.([]defaultField), nil
+	}
+
+	var fields []defaultField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			nested, err := defaultFields(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			for _, df := range nested {
+				df.index = append([]int{i}, df.index...)
+				fields = append(fields, df)
+			}
+			continue
+		}
+
+		value, ok := field.Tag.Lookup("default")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		df := defaultField{index: []int{i}, name: t.String() + "." + field.Name, value: value, layout: field.Tag.Get("time_format")}
+		if err := df.set(reflect.New(field.Type).Elem()); err != nil {
+			return nil, err
+		}
+		fields = append(fields, df)
+	}
+
+	defaultsCache.Store(t, fields)
+	return fields, nil
+}
+
+// set 将默认值转换为字段的类型后赋值
+func (df defaultField) set(field reflect.Value) error {
+	if err := setValues(field, []string{df.value}, df.layout, ","); err != nil {
+		return fmt.Errorf("invalid default value %q of %s: %w", df.value, df.name, err)
+	}
+	return nil
+}
+
+// applyDefaults 为没有传递的字段设置 default 标签声明的默认值，在校验之前执行：
+//
+//	type UserListReq struct {
+//		Page  int           `form:"page" default:"1"`
+//		Size  *int          `form:"size" default:"20"`       // 指针只在 nil 时使用默认值，?size=0 保持为 0
+//		Sort  []string      `form:"sort" default:"id,name"`  // 切片与 map 的默认值按逗号拆分
+//		Since time.Duration `form:"since" default:"24h"`
+//	}
+//
+// 非指针字段无法区分“未传”与零值，零值都会被替换为默认值；需要区分时使用指针
+func applyDefaults(point any) error {
+	v := reflect.ValueOf(point)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields, err := defaultFields(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, df := range fields {
+		field := v.FieldByIndex(df.index)
+		if !field.IsZero() {
+			continue
+		}
+		if err := df.set(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
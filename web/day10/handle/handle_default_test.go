@@ -0,0 +1,152 @@
+package handle_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	SearchReq struct {
+		Page   int               `form:"page" json:"page" default:"1"`
+		Size   *int              `form:"size" json:"size" default:"20"`
+		Ids    []int             `form:"id" json:"ids" split:","`
+		Tags   []string          `form:"tag" json:"tags" default:"a,b"`
+		Sort   map[string]string `in:"query" form:"sort" json:"sort"`
+		Within time.Duration     `in:"query" form:"within" json:"within" default:"24h"`
+		After  *time.Time        `in:"query" form:"after" json:"after" time_format:"2006-01-02"`
+	}
+	SearchRes = SearchReq
+)
+
+func Search(ctx context.Context, req *SearchReq) (res *SearchRes, err error) {
+	return req, nil
+}
+
+func TestDefaults(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/search", handle.Typed(Search).Handler())
+	r.POST("/search", handle.Typed(Search).Handler())
+
+	after := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	size := func(n int) *int { return &n }
+
+	tests := []struct {
+		name, method, target, contentType, body string
+		status                                  int
+		want                                    SearchRes
+	}{
+		{
+			name:   "defaults",
+			method: "GET", target: "/search",
+			status: 200, want: SearchRes{Page: 1, Size: size(20), Tags: []string{"a", "b"}, Within: 24 * time.Hour},
+		},
+		{
+			// 指针可以区分“未传”与零值
+			name:   "pointer presence",
+			method: "GET", target: "/search?page=0&size=0",
+			status: 200, want: SearchRes{Page: 1, Size: size(0), Tags: []string{"a", "b"}, Within: 24 * time.Hour},
+		},
+		{
+			name:   "coercion",
+			method: "GET", target: "/search?page=2&id=1,2&id=3&tag=x&sort[name]=asc&sort=age=desc&within=90m&after=2024-01-02",
+			status: 200, want: SearchRes{
+				Page: 2, Size: size(20), Ids: []int{1, 2, 3}, Tags: []string{"x"},
+				Sort:   map[string]string{"name": "asc", "age": "desc"},
+				Within: 90 * time.Minute, After: &after,
+			},
+		},
+		{
+			name:   "form body",
+			method: "POST", target: "/search?within=1h", contentType: "application/x-www-form-urlencoded", body: "id=4,5&size=5",
+			status: 200, want: SearchRes{Page: 1, Size: size(5), Ids: []int{4, 5}, Tags: []string{"a", "b"}, Within: time.Hour},
+		},
+		{
+			// 没有 split 标签的切片不拆分请求中的值，default 标签的值仍然按逗号拆分
+			name:   "no split",
+			method: "GET", target: "/search?tag=x,y&tag=z",
+			status: 200, want: SearchRes{Page: 1, Size: size(20), Tags: []string{"x,y", "z"}, Within: 24 * time.Hour},
+		},
+		{name: "invalid slice", method: "GET", target: "/search?id=1,x", status: 400},
+		{name: "invalid map", method: "GET", target: "/search?sort=name", status: 400},
+		{name: "invalid duration", method: "GET", target: "/search?within=1", status: 400},
+		{name: "invalid time", method: "GET", target: "/search?after=2024", status: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != 200 {
+				return
+			}
+			var resp struct {
+				Data json.RawMessage `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			want, _ := json.Marshal(tt.want)
+			if string(resp.Data) != string(want) {
+				t.Errorf("data = %s, want %s", resp.Data, want)
+			}
+		})
+	}
+}
+
+type BadDefaultReq struct {
+	Page int `form:"page" default:"abc"`
+}
+
+func TestDefaultsInvalid(t *testing.T) {
+	// 默认值在构造时转换为字段的类型，而不是在每次请求时返回 500
+	_, err := handle.TryNewReqResFunc(func(ctx context.Context, req *BadDefaultReq) error { return nil })
+	var se *handle.SignatureError
+	if !errors.As(err, &se) || se.Rule != handle.RuleDefault || !strings.Contains(se.Msg, `invalid default value "abc" of handle_test.BadDefaultReq.Page`) {
+		t.Errorf("got %v, want rule %s", err, handle.RuleDefault)
+	}
+
+	defer func() {
+		if se, ok := recover().(*handle.SignatureError); !ok || se.Rule != handle.RuleDefault {
+			t.Errorf("Typed should panic with rule %s, got %v", handle.RuleDefault, se)
+		}
+	}()
+	handle.Typed(func(ctx context.Context, req *BadDefaultReq) (*EchoRes, error) { return nil, nil })
+}
+
+func TestDefaultsOpenAPI(t *testing.T) {
+	doc := handle.OpenAPI(handle.OpenAPIInfo{}, []handle.Route{
+		{Method: "GET", Path: "/search", Func: handle.Typed(Search)},
+	})
+
+	defaults := map[string]any{}
+	for _, p := range doc.Paths["/search"]["get"].Parameters {
+		if p.Schema.Default != nil {
+			defaults[p.Name] = p.Schema.Default
+		}
+	}
+	want := map[string]any{"page": float64(1), "size": float64(20), "tag": []any{"a", "b"}, "within": "24h"}
+	for name, value := range want {
+		if !reflect.DeepEqual(defaults[name], value) {
+			t.Errorf("default of %s = %v, want %v", name, defaults[name], value)
+		}
+	}
+}
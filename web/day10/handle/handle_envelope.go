@@ -0,0 +1,41 @@
+package handle
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Envelope 控制成功与失败的返回值如何包装
+type Envelope interface {
+	// Success 包装成功的返回值，返回 HTTP 状态码与响应体
+	Success(c *gin.Context, data any) (status int, body any)
+	// Failure 包装错误，返回 HTTP 状态码与响应体，err 已经过 ToError 转换与本地化
+	Failure(c *gin.Context, err *Error) (status int, body any)
+}
+
+// Encoder 将响应体写入 c
+type Encoder func(c *gin.Context, status int, body any)
+
+// JSONEncoder 以 JSON 格式写入响应体
+func JSONEncoder(c *gin.Context, status int, body any) { c.JSON(status, body) }
+
+// StandardEnvelope 默认的包装方式：{"code":200,"msg":"","data":{}}
+type StandardEnvelope struct{}
+
+func (StandardEnvelope) Success(c *gin.Context, data any) (int, any) {
+	return http.StatusOK, Response{Code: CodeOK, Msg: "", Data: data, RequestId: requestID(c)}
+}
+
+func (StandardEnvelope) Failure(c *gin.Context, err *Error) (int, any) {
+	return err.Status, Response{Code: err.Code, Msg: err.Msg, Data: err.Details, RequestId: requestID(c)}
+}
+
+// BareEnvelope 成功时直接返回数据，不做包装；失败时与 StandardEnvelope 一致
+type BareEnvelope struct{}
+
+func (BareEnvelope) Success(c *gin.Context, data any) (int, any) { return http.StatusOK, data }
+
+func (BareEnvelope) Failure(c *gin.Context, err *Error) (int, any) {
+	return StandardEnvelope{}.Failure(c, err)
+}
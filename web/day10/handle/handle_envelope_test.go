@@ -0,0 +1,82 @@
+package handle_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resultEnvelope {success,error,result} 格式，并附加 requestId 与 timestamp
+type resultEnvelope struct{}
+
+type result struct {
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+	Result    any    `json:"result,omitempty"`
+	RequestId string `json:"requestId"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+func (resultEnvelope) Success(c *gin.Context, data any) (int, any) {
+	return http.StatusOK, result{Success: true, Result: data, RequestId: c.GetHeader("X-Request-ID"), Timestamp: 1700000000}
+}
+
+func (resultEnvelope) Failure(c *gin.Context, err *handle.Error) (int, any) {
+	return err.Status, result{Success: false, Error: err.Msg, RequestId: c.GetHeader("X-Request-ID"), Timestamp: 1700000000}
+}
+
+func TestEnvelope(t *testing.T) {
+	ok := func(ctx context.Context, decode func(point any) error) (any, error) {
+		return map[string]int{"id": 1}, nil
+	}
+	fail := func(ctx context.Context, decode func(point any) error) (any, error) {
+		return nil, handle.NewError(handle.CodeForbidden, http.StatusForbidden, "no permission")
+	}
+	text := func(c *gin.Context, status int, body any) {
+		c.String(status, "%v", body)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/standard", handle.Handle(ok))
+	r.GET("/bare", handle.Handle(ok, handle.WithEnvelope(handle.BareEnvelope{})))
+	r.GET("/bare/fail", handle.Handle(fail, handle.WithEnvelope(handle.BareEnvelope{})))
+	r.GET("/result", handle.Handle(ok, handle.WithEnvelope(resultEnvelope{})))
+	r.GET("/result/fail", handle.Handle(fail, handle.WithEnvelope(resultEnvelope{})))
+	r.GET("/text", handle.Handle(ok, handle.WithEnvelope(handle.BareEnvelope{}), handle.WithEncoder(text)))
+
+	handle.SetDefaultOptions(handle.WithEnvelope(resultEnvelope{}))
+	r.GET("/default", handle.Handle(ok))
+	r.GET("/default/override", handle.Handle(ok, handle.WithEnvelope(handle.BareEnvelope{})))
+	handle.SetDefaultOptions()
+
+	tests := []struct {
+		target string
+		status int
+		want   string
+	}{
+		{"/standard", 200, `{"code":200,"msg":"","data":{"id":1}}`},
+		{"/bare", 200, `{"id":1}`},
+		{"/bare/fail", 403, `{"code":403,"msg":"no permission","data":null}`},
+		{"/result", 200, `{"success":true,"result":{"id":1},"requestId":"abc","timestamp":1700000000}`},
+		{"/result/fail", 403, `{"success":false,"error":"no permission","requestId":"abc","timestamp":1700000000}`},
+		{"/text", 200, `map[id:1]`},
+		{"/default", 200, `{"success":true,"result":{"id":1},"requestId":"abc","timestamp":1700000000}`},
+		{"/default/override", 200, `{"id":1}`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		req.Header.Set("X-Request-ID", "abc")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status || w.Body.String() != tt.want {
+			t.Errorf("%s: got %d %s, want %d %s", tt.target, w.Code, w.Body, tt.status, tt.want)
+		}
+	}
+}
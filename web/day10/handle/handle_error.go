@@ -0,0 +1,123 @@
+package handle
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Error 业务错误，携带业务代码、HTTP 状态码、错误消息以及可选的错误详情
+type Error struct {
+	Code    int    // 业务代码，对应 Response.Code
+	Status  int    // HTTP 状态码
+	Msg     string // 错误消息，对应 Response.Msg
+	Details any    // 错误详情，对应 Response.Data
+	Key     string // 消息目录 Catalog 中的 key，缺省为业务代码
+
+	err error // 原始错误，不会返回给客户端
+}
+
+// NewError 返回 *Error，msg 为空时使用 HTTP 状态码对应的描述
+func NewError(code, status int, msg string) *Error {
+	if msg == "" {
+		msg = http.StatusText(status)
+	}
+	return &Error{Code: code, Status: status, Msg: msg}
+}
+
+func (e *Error) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("code=%d status=%d msg=%s: %v", e.Code, e.Status, e.Msg, e.err)
+	}
+	return fmt.Sprintf("code=%d status=%d msg=%s", e.Code, e.Status, e.Msg)
+}
+
+func (e *Error) Unwrap() error { return e.err }
+
+// Wrap 返回携带原始错误 err 的副本
+func (e *Error) Wrap(err error) *Error {
+	clone := *e
+	clone.err = err
+	return &clone
+}
+
+// WithDetails 返回携带错误详情 details 的副本
+func (e *Error) WithDetails(details any) *Error {
+	clone := *e
+	clone.Details = details
+	return &clone
+}
+
+// errorMapping 错误与业务代码的映射规则
+type errorMapping struct {
+	match  func(err error) bool
+	code   int
+	status int
+}
+
+var (
+	mappingsMu    sync.RWMutex
+	errorMappings []errorMapping
+)
+
+// RegisterError 注册哨兵错误 target 与业务代码、HTTP 状态码的映射，通过 errors.Is 匹配
+//
+// 匹配成功时，status < 500 使用 err.Error() 作为错误消息，否则使用 HTTP 状态码对应的描述，避免泄露内部信息
+func RegisterError(target error, code, status int) {
+	addMapping(errorMapping{
+		match:  func(err error) bool { return errors.Is(err, target) },
+		code:   code,
+		status: status,
+	})
+}
+
+// RegisterErrorType 注册错误类型 T 与业务代码、HTTP 状态码的映射，通过 errors.As 匹配
+func RegisterErrorType[T error](code, status int) {
+	addMapping(errorMapping{
+		match:  func(err error) bool { var target T; return errors.As(err, &target) },
+		code:   code,
+		status: status,
+	})
+}
+
+func addMapping(m errorMapping) {
+	mappingsMu.Lock()
+	defer mappingsMu.Unlock()
+
+	errorMappings = append(errorMappings, m)
+}
+
+// ToError 将任意错误转换为 *Error
+//
+// 1. 错误链中存在 *Error，直接返回
+// 2. 按注册顺序查找映射规则
+// 3. 未知错误统一转换为 CodeInternal，不泄露内部信息
+func ToError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	mappingsMu.RLock()
+	defer mappingsMu.RUnlock()
+
+	for _, m := range errorMappings {
+		if !m.match(err) {
+			continue
+		}
+
+		msg := ""
+		if m.status < http.StatusInternalServerError {
+			msg = err.Error()
+		}
+		return NewError(m.code, m.status, msg).Wrap(err)
+	}
+
+	return NewError(CodeInternal, http.StatusInternalServerError, "").Wrap(err)
+}
+
+// badRequest 请求参数异常，用于包装反序列化的错误
+func badRequest(err error) *Error {
+	return NewError(CodeBadRequest, http.StatusBadRequest, err.Error()).Wrap(err)
+}
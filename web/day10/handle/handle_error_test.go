@@ -0,0 +1,95 @@
+package handle_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errNotFound = errors.New("not found")
+	errInternal = errors.New("internal error")
+)
+
+type quotaError struct{ limit int }
+
+func (e *quotaError) Error() string { return fmt.Sprintf("quota exceeded: %d", e.limit) }
+
+func init() {
+	handle.RegisterError(errNotFound, handle.CodeNotFound, http.StatusNotFound)
+	handle.RegisterError(errInternal, handle.CodeInternal, http.StatusInternalServerError)
+	handle.RegisterErrorType[*quotaError](429, http.StatusTooManyRequests)
+}
+
+// serve 注册 handler 并发起一次请求，返回 HTTP 状态码与响应体
+func serve(t *testing.T, method, path, target string, h gin.HandlerFunc, body ...string) (int, handle.Response) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Handle(method, path, h)
+
+	req := httptest.NewRequest(method, target, nil)
+	if len(body) > 0 {
+		req = httptest.NewRequest(method, target, strings.NewReader(body[0]))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp handle.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response %q: %v", w.Body.String(), err)
+	}
+	return w.Code, resp
+}
+
+func TestToError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   int
+		msg    string
+	}{
+		{"sentinel", fmt.Errorf("user %w: %d", errNotFound, 3), 404, handle.CodeNotFound, "user not found: 3"},
+		{"type", fmt.Errorf("wrap: %w", &quotaError{limit: 10}), 429, 429, "wrap: quota exceeded: 10"},
+		{"internal", fmt.Errorf("db: %w", errInternal), 500, handle.CodeInternal, "Internal Server Error"},
+		{"unknown", errors.New("dial tcp 10.0.0.1:3306: connection refused"), 500, handle.CodeInternal, "Internal Server Error"},
+		{"handle.Error", handle.NewError(1001, http.StatusForbidden, "no permission"), 403, 1001, "no permission"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := serve(t, http.MethodGet, "/", "/", handle.Handle(func(ctx context.Context, decode func(point any) error) (any, error) {
+				return nil, tt.err
+			}))
+
+			if status != tt.status || resp.Code != tt.code || resp.Msg != tt.msg {
+				t.Errorf("got (%d, %d, %q), want (%d, %d, %q)", status, resp.Code, resp.Msg, tt.status, tt.code, tt.msg)
+			}
+		})
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	status, resp := serve(t, http.MethodGet, "/user/:id", "/user/abc", handle.Handle(func(ctx context.Context, decode func(point any) error) (any, error) {
+		var req struct {
+			Id int `uri:"id"`
+		}
+		return nil, decode(&req)
+	}))
+
+	if status != http.StatusBadRequest || resp.Code != handle.CodeBadRequest {
+		t.Errorf("got (%d, %d), want (%d, %d)", status, resp.Code, http.StatusBadRequest, handle.CodeBadRequest)
+	}
+}
@@ -0,0 +1,38 @@
+package handle
+
+import (
+	"embed"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed explorer
+var explorerFS embed.FS
+
+// MountExplorer 在 router 上挂载 API 浏览器，如 path 为 "/explorer" 时：
+//
+//	GET /explorer/              页面，列出通过 handle 注册的路由，可以在线发送请求
+//	GET /explorer/openapi.json  页面使用的 OpenAPI 文档
+//
+// 页面的所有资源都通过 embed.FS 内嵌，不依赖 CDN，可以离线使用
+func MountExplorer(router gin.IRouter, path string, info OpenAPIInfo) {
+	path = strings.TrimSuffix(path, "/")
+
+	index, err := explorerFS.ReadFile("explorer/index.html")
+	if err != nil {
+		panic(err) // 内嵌文件缺失，只会在开发阶段出现
+	}
+
+	page := func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	}
+	router.GET(path, func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, c.Request.URL.Path+"/")
+	})
+	router.GET(path+"/", page)
+	router.GET(path+"/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, OpenAPI(info, Routes()))
+	})
+}
@@ -0,0 +1,36 @@
+package handle_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMountExplorer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	handle.MountExplorer(r, "/explorer", handle.OpenAPIInfo{Title: "test", Version: "v1"})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/explorer/", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	// 页面不能引用外部资源
+	if external := regexp.MustCompile(`(src|href)=["']?(https?:)?//`).FindString(w.Body.String()); external != "" {
+		t.Errorf("explorer should not load external assets, found %q", external)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/explorer/openapi.json", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"openapi":"3.1.0"`) {
+		t.Errorf("got %d %s", w.Code, w.Body)
+	}
+}
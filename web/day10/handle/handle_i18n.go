@@ -0,0 +1,180 @@
+package handle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// Catalog 消息目录，key 为业务代码（如 "404"）、Error.Key 或校验规则（如 "required"、"min.length"），
+// value 为消息模板，支持以下占位符：
+//
+//	{msg}    原始的错误消息，未经翻译，不宜用于面向用户的消息
+//	{code}   业务代码
+//	{field}  校验失败的字段名
+//	{param}  校验规则的参数
+type Catalog map[string]string
+
+var (
+	catalogsMu  sync.RWMutex
+	catalogs    = map[string]Catalog{} // 语言 -> 消息目录，语言统一为小写，如 zh-cn
+	defaultLang = "en"
+)
+
+// RegisterCatalog 注册语言 lang 的消息目录，重复注册时合并，后注册的优先
+func RegisterCatalog(lang string, catalog Catalog) {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+
+	lang = strings.ToLower(lang)
+	if catalogs[lang] == nil {
+		catalogs[lang] = Catalog{}
+	}
+	for k, v := range catalog {
+		catalogs[lang][k] = v
+	}
+}
+
+// SetDefaultLanguage 设置默认语言，请求没有 Accept-Language 或没有匹配的消息目录时使用，默认为 en
+func SetDefaultLanguage(lang string) {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+
+	defaultLang = strings.ToLower(lang)
+}
+
+// LoadCatalog 从 JSON 或 YAML 文件加载语言 lang 的消息目录，格式由文件扩展名决定
+func LoadCatalog(lang, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return parseCatalog(lang, path, data)
+}
+
+// LoadCatalogFS 从 fsys 中加载语言 lang 的消息目录，常用于 embed.FS
+func LoadCatalogFS(fsys fs.FS, lang, name string) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	return parseCatalog(lang, name, data)
+}
+
+func parseCatalog(lang, name string, data []byte) error {
+	catalog := Catalog{}
+
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".json":
+		err = json.Unmarshal(data, &catalog)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &catalog)
+	default:
+		return fmt.Errorf("unsupported catalog format %q of %s", ext, name)
+	}
+	if err != nil {
+		return fmt.Errorf("parse catalog %s: %w", name, err)
+	}
+
+	RegisterCatalog(lang, catalog)
+	return nil
+}
+
+// languages 解析 Accept-Language，按权重从高到低返回语言，如 zh-CN,zh;q=0.9,en;q=0.8 -> [zh-cn zh en]
+func languages(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+
+	var ws []weighted
+	for _, part := range strings.Split(header, ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if lang == "" || lang == "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			ws = append(ws, weighted{lang: strings.ToLower(lang), q: q})
+		}
+	}
+	sort.SliceStable(ws, func(i, j int) bool { return ws[i].q > ws[j].q })
+
+	langs := make([]string, len(ws))
+	for i, w := range ws {
+		langs[i] = w.lang
+	}
+	return langs
+}
+
+// catalogFor 返回与 Accept-Language 匹配的消息目录，依次尝试完整的语言（zh-cn）、主语言（zh）与默认语言
+func catalogFor(acceptLanguage string) Catalog {
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	for _, lang := range languages(acceptLanguage) {
+		if catalog, ok := catalogs[lang]; ok {
+			return catalog
+		}
+		if base, _, ok := strings.Cut(lang, "-"); ok {
+			if catalog, ok := catalogs[base]; ok {
+				return catalog
+			}
+		}
+	}
+	return catalogs[defaultLang]
+}
+
+// format 替换消息模板中的占位符
+func format(template string, args map[string]string) string {
+	for k, v := range args {
+		template = strings.ReplaceAll(template, "{"+k+"}", v)
+	}
+	return template
+}
+
+// localize 根据请求的 Accept-Language 翻译错误消息与校验错误，返回 e 的副本；没有匹配的消息时保留原始消息
+func localize(c *gin.Context, e *Error) *Error {
+	catalog := catalogFor(c.GetHeader("Accept-Language"))
+	if len(catalog) == 0 {
+		return e
+	}
+
+	clone := *e
+	code := strconv.Itoa(e.Code)
+	key := e.Key
+	if key == "" {
+		key = code
+	}
+	if template, ok := catalog[key]; ok {
+		clone.Msg = format(template, map[string]string{"msg": e.Msg, "code": code})
+	}
+
+	if errs, ok := e.Details.(ValidationErrors); ok {
+		localized := make(ValidationErrors, len(errs))
+		for i, fe := range errs {
+			localized[i] = fe
+			if template, ok := catalog[fe.key]; ok {
+				localized[i].Message = format(template, map[string]string{"field": fe.Field, "param": fe.Param, "msg": fe.Message})
+			}
+		}
+		clone.Details = localized
+	}
+	return &clone
+}
@@ -0,0 +1,65 @@
+package handle_test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLocalize(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "zh.yaml")
+	jsonFile := filepath.Join(dir, "zh-TW.json")
+	_ = os.WriteFile(yamlFile, []byte("\"404\": \"资源不存在：{msg}\"\nvalidation: 请求参数校验失败\nrequired: \"{field} 不能为空\"\nmin.length: \"{field} 的长度不能小于 {param}\"\n"), 0o644)
+	_ = os.WriteFile(jsonFile, []byte(`{"404": "資源不存在"}`), 0o644)
+
+	if err := handle.LoadCatalog("zh", yamlFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := handle.LoadCatalog("zh-TW", jsonFile); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/not-found", handle.Handle(func(ctx context.Context, decode func(point any) error) (any, error) {
+		return nil, fmt.Errorf("user %w: %d", errNotFound, 3)
+	}))
+	r.POST("/sign-up", handle.Typed(SignUp).Handler())
+
+	tests := []struct {
+		method, target, body, lang string
+		want                       string
+	}{
+		{"GET", "/not-found", "", "", `{"code":404,"msg":"user not found: 3","data":null}`},
+		{"GET", "/not-found", "", "zh-CN,zh;q=0.9,en;q=0.8", `{"code":404,"msg":"资源不存在：user not found: 3","data":null}`},
+		{"GET", "/not-found", "", "en;q=0.5,zh-TW", `{"code":404,"msg":"資源不存在","data":null}`},
+		{"GET", "/not-found", "", "fr", `{"code":404,"msg":"user not found: 3","data":null}`},
+		{
+			"POST", "/sign-up", `{"name":"a","age":18,"password":"x","confirm":"x","end":1}`, "zh",
+			`{"code":400,"msg":"请求参数校验失败","data":[{"field":"name","rule":"min","message":"name 的长度不能小于 2"},{"field":"code","rule":"len","message":"code length must be 6"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.lang != "" {
+			req.Header.Set("Accept-Language", tt.lang)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("%s %s (%s)\ngot  %s\nwant %s", tt.method, tt.target, tt.lang, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package handle
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Invocation 一次处理函数的调用，在请求参数解析并校验之后构造
+type Invocation struct {
+	Name string            // 方法名，与 SignatureError.Method 相同，如 User.GetById
+	Meta reflect.StructTag // 请求参数 meta 字段的标签，可以读取 method、path、summary 等路由元数据，没有时为空
+	Req  any               // 请求参数，为结构体指针，可以修改或替换为同类型的指针后传递给处理函数；没有请求参数时为 nil
+}
+
+// Invoker 调用拦截器链中的下一个拦截器，最后调用处理函数
+type Invoker func(ctx context.Context) (res any, err error)
+
+// Interceptor 处理函数的拦截器，与 gin 的中间件不同，拦截器能读取解析后的请求参数与处理函数的返回值：
+//
+//	func Audit(ctx context.Context, inv *handle.Invocation, next handle.Invoker) (any, error) {
+//		res, err := next(ctx) // 不调用 next 时短路，直接返回 res 与 err
+//		log.Printf("%s %s req=%+v err=%v", inv.Meta.Get("method"), inv.Meta.Get("path"), inv.Req, err)
+//		return res, err       // 可以替换返回值或错误
+//	}
+//
+// 拦截器可以作用于三个范围，按以下顺序由外到内执行：
+//
+//  1. 全局：handle.SetDefaultOptions(handle.WithInterceptors(Audit))
+//  2. 结构体：handle.RegisterObject(r, controller.User, handle.WithInterceptors(Auth))
+//  3. 方法：先通过 handle.RegisterInterceptor("cache", Cache) 注册，再在 meta 标签中引用：`interceptor:"cache"`
+//
+// 传递给 next 的 ctx 必须能赋值给处理函数的第一个参数，处理函数声明为 context.Context 时可以替换为派生的 ctx
+type Interceptor func(ctx context.Context, inv *Invocation, next Invoker) (res any, err error)
+
+var (
+	interceptorsMu sync.RWMutex
+	interceptors   = map[string][]Interceptor{}
+)
+
+// RegisterInterceptor 注册具名拦截器，供 meta 标签的 interceptor 引用，需要在构造处理函数之前注册
+func RegisterInterceptor(name string, ics ...Interceptor) {
+	interceptorsMu.Lock()
+	defer interceptorsMu.Unlock()
+
+	interceptors[name] = ics
+}
+
+// WithInterceptors 追加处理函数的拦截器，在 NewReqResFunc、Typed、ObjectHandler、RegisterObject 与 NewWebSocket 中生效，
+// 多次设置时依次追加
+func WithInterceptors(ics ...Interceptor) Option {
+	return func(o *options) {
+		o.interceptors = append(o.interceptors[:len(o.interceptors):len(o.interceptors)], ics...)
+	}
+}
+
+// metaInterceptors 返回请求参数 meta 字段的标签，以及标签 interceptor 引用的拦截器
+func metaInterceptors(req reflect.Type) (reflect.StructTag, []Interceptor, error) {
+	if req == nil || structOf(req).Kind() != reflect.Struct {
+		return "", nil, nil
+	}
+	field, ok := structOf(req).FieldByName("meta")
+	if !ok {
+		return "", nil, nil
+	}
+
+	interceptorsMu.RLock()
+	defer interceptorsMu.RUnlock()
+
+	var ics []Interceptor
+	for _, name := range splitTag(field.Tag.Get("interceptor")) {
+		ic, ok := interceptors[name]
+		if !ok {
+			return field.Tag, nil, fmt.Errorf("interceptor %q is not registered", name)
+		}
+		ics = append(ics, ic...)
+	}
+	return field.Tag, ics, nil
+}
+
+// intercept 依次执行拦截器，最后调用 call
+func intercept(ctx context.Context, inv *Invocation, ics []Interceptor, call Invoker) (any, error) {
+	next := call
+	for i := len(ics) - 1; i >= 0; i-- {
+		ic, inner := ics[i], next
+		next = func(ctx context.Context) (any, error) { return ic(ctx, inv, inner) }
+	}
+	return next(ctx)
+}
@@ -0,0 +1,128 @@
+package handle_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	OrderGetReq struct {
+		meta struct{} `method:"GET" path:"/order/:id" interceptor:"cache"`
+		Id   int      `uri:"id"`
+	}
+	OrderGetRes struct {
+		Id    int    `json:"id"`
+		Trace string `json:"trace"`
+	}
+
+	OrderCancelReq struct {
+		meta struct{} `method:"POST" path:"/order/:id/cancel"`
+		Id   int      `uri:"id"`
+	}
+)
+
+type Order struct{ calls int }
+
+func (o *Order) Get(ctx context.Context, req *OrderGetReq) (*OrderGetRes, error) {
+	o.calls++
+	return &OrderGetRes{Id: req.Id}, nil
+}
+
+func (o *Order) Cancel(ctx context.Context, req *OrderCancelReq) error {
+	return nil
+}
+
+type BrokenInterceptorReq struct {
+	meta struct{} `method:"GET" path:"/broken" interceptor:"missing"`
+}
+
+// traced 记录拦截器的执行顺序
+func traced(name string) handle.Interceptor {
+	return func(ctx context.Context, inv *handle.Invocation, next handle.Invoker) (any, error) {
+		res, err := next(ctx)
+		if r, ok := res.(*OrderGetRes); ok {
+			r.Trace = name + "/" + r.Trace
+		}
+		return res, err
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	handle.SetDefaultOptions(handle.WithInterceptors(traced("global")))
+	defer handle.SetDefaultOptions()
+
+	cached := map[int]*OrderGetRes{7: {Id: 7, Trace: "cached"}}
+	handle.RegisterInterceptor("cache", func(ctx context.Context, inv *handle.Invocation, next handle.Invoker) (any, error) {
+		if res, ok := cached[inv.Req.(*OrderGetReq).Id]; ok {
+			return res, nil // 短路，不调用处理函数
+		}
+		return next(ctx)
+	})
+
+	// 结构体的拦截器读取路由元数据与请求参数
+	forbid := func(ctx context.Context, inv *handle.Invocation, next handle.Invoker) (any, error) {
+		if req, ok := inv.Req.(*OrderCancelReq); ok && req.Id == 0 {
+			return nil, handle.NewError(handle.CodeForbidden, http.StatusForbidden, inv.Meta.Get("path"))
+		}
+		return next(ctx)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	order := &Order{}
+	if err := handle.RegisterObject(r, order, handle.WithInterceptors(forbid, traced("object"))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, target string
+		status         int
+		body           string
+	}{
+		{http.MethodGet, "/order/1", http.StatusOK, `"trace":"global/object/"`},
+		{http.MethodGet, "/order/7", http.StatusOK, `"trace":"global/object/cached"`},
+		{http.MethodPost, "/order/0/cancel", http.StatusForbidden, `"msg":"/order/:id/cancel"`},
+		{http.MethodPost, "/order/1/cancel", http.StatusOK, `"data":null`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s %s: got %d %s, want %d %s", tt.method, tt.target, w.Code, w.Body, tt.status, tt.body)
+		}
+	}
+	if order.calls != 1 {
+		t.Errorf("handler called %d times, want 1", order.calls)
+	}
+
+	_, err := handle.TryNewReqResFunc(func(ctx context.Context, req *BrokenInterceptorReq) error { return nil })
+	var se *handle.SignatureError
+	if !errors.As(err, &se) || se.Rule != handle.RuleInterceptor {
+		t.Errorf("got %v, want rule %s", err, handle.RuleInterceptor)
+	}
+}
+
+func TestInterceptorTyped(t *testing.T) {
+	replace := func(ctx context.Context, inv *handle.Invocation, next handle.Invoker) (any, error) {
+		inv.Req.(*EchoReq).Name = "intercepted"
+		return next(ctx)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/echo/:id", handle.Typed(Echo, handle.WithInterceptors(replace)).Handler())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/echo/1?name=gee", nil))
+	if !strings.Contains(w.Body.String(), "intercepted") {
+		t.Errorf("got %s", w.Body)
+	}
+}
@@ -0,0 +1,185 @@
+package handle
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Split 方法名转换为请求路径时，单词之间的分隔方式
+type Split int
+
+const (
+	SplitSlash Split = iota // GetHelloWorld -> GET /hello/world
+	SplitKebab              // GetHelloWorld -> GET /hello-world
+)
+
+// NameOption 方法名路由的配置
+type NameOption struct {
+	Split Split // 单词分隔方式，默认为 SplitSlash
+}
+
+// verbs 可以识别的请求方法前缀
+var verbs = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodHead, http.MethodOptions,
+}
+
+// RegisterByName 通过结构体（对象）注册路由，路由信息读取自方法名：
+//
+//	GetHelloWorld    -> GET  /hello/world
+//	GetUserBy        -> GET  /user/:id
+//	PostUserByTeamBy -> POST /user/:id/team/:teamId
+//
+// 方法名必须以 Get/Post/Put/Patch/Delete/Head/Options 开头，
+// 单词 By 对应一个动态路由参数，参数名依次取自 `XxxReq` 中的 uri 标签，缺省为 id，
+// opts 会应用到所有方法的 handler
+//
+// 参考：[examples/mvc/hello-world/main.go](https://github.com/iris-contrib/examples/blob/master/mvc/hello-world/main.go)
+func RegisterByName(router gin.IRouter, object any, opt NameOption, opts ...Option) error {
+	rs, nameErr := NameRoutes(object, opt, opts...)
+	for i := range rs {
+		rs[i].Options = append(append([]Option(nil), opts...), rs[i].Options...)
+	}
+	handlers, err := prepareRoutes(router, rs)
+	if err := errors.Join(nameErr, err); err != nil {
+		return err
+	}
+	return applyRoutes(router, rs, handlers)
+}
+
+// NameRoutes 根据结构体所有方法的方法名，返回路由元数据，可以配合 PrintRoutes 预览路由表，
+// opts 中只有 WithNaming 会生效
+func NameRoutes(object any, opt NameOption, opts ...Option) ([]Route, error) {
+	var (
+		rs   []Route
+		errs []error
+	)
+	err := TryObjectHandler(object, func(fn *ReqResFunc, methodName string) {
+		route, err := nameRoute(fn, methodName, opt)
+		route.Name = objectName(object) + "." + methodName
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", route.Name, err))
+			return
+		}
+		rs = append(rs, route)
+	}, opts...)
+	return rs, errors.Join(append([]error{err}, errs...)...)
+}
+
+// nameRoute 将方法名转换为请求方法与请求路径
+func nameRoute(fn Func, methodName string, opt NameOption) (Route, error) {
+	route := Route{Func: fn}
+
+	words := splitWords(methodName)
+	if len(words) > 0 {
+		route.Method = strings.ToUpper(words[0])
+	}
+	if !validMethod(route.Method) {
+		return route, fmt.Errorf("method name %q must start with one of %s", methodName, strings.Join(verbs, "/"))
+	}
+
+	sep := "/"
+	if opt.Split == SplitKebab {
+		sep = "-"
+	}
+
+	params := uriParams(fn)
+	var (
+		segments []string
+		segment  []string
+	)
+	flush := func() {
+		if len(segment) > 0 {
+			segments = append(segments, strings.Join(segment, sep))
+			segment = nil
+		}
+	}
+	for _, word := range words[1:] {
+		if word != "By" {
+			segment = append(segment, strings.ToLower(word))
+			continue
+		}
+
+		flush()
+		n := countParams(segments)
+		switch {
+		case n < len(params):
+			segments = append(segments, ":"+params[n])
+		case n == 0:
+			segments = append(segments, ":id")
+		default:
+			return route, fmt.Errorf(`method name %q has more "By" segments than uri fields of %s`, methodName, fn.Req())
+		}
+	}
+	flush()
+
+	route.Path = "/" + strings.Join(segments, "/")
+	return route, nil
+}
+
+func countParams(segments []string) int {
+	n := 0
+	for _, s := range segments {
+		if strings.HasPrefix(s, ":") {
+			n++
+		}
+	}
+	return n
+}
+
+// uriParams 返回请求参数中 uri 标签的值
+func uriParams(fn Func) []string {
+	var params []string
+
+	t := fn.Req()
+	if t == nil {
+		return nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("uri"), ","); name != "" && name != "-" {
+			params = append(params, name)
+		}
+	}
+	return params
+}
+
+// splitWords 按驼峰拆分单词，连续的大写字母视为一个单词，如 GetUserID -> [Get User ID]
+func splitWords(name string) []string {
+	var (
+		words []string
+		runes = []rune(name)
+		start = 0
+	)
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		if unicode.IsUpper(cur) && (!unicode.IsUpper(prev) || unicode.IsLower(next)) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// PrintRoutes 以表格形式打印路由表，用于在注册之前预览路由
+func PrintRoutes(w io.Writer, rs []Route) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, route := range rs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", route.Method, route.Path, route.Name)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,96 @@
+package handle_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	MemberGetReq struct {
+		Id int `uri:"id"`
+	}
+	MemberGetRes struct{}
+
+	MemberJoinReq struct {
+		Id     int `uri:"id"`
+		TeamId int `uri:"teamId"`
+	}
+	MemberJoinRes struct{}
+
+	MemberLeaveReq struct{}
+	MemberLeaveRes struct{}
+)
+
+type Member struct{}
+
+func (c *Member) GetUserBy(ctx context.Context, req *MemberGetReq) (res *MemberGetRes, err error) {
+	return &MemberGetRes{}, nil
+}
+
+func (c *Member) PostUserByTeamBy(ctx context.Context, req *MemberJoinReq) (res *MemberJoinRes, err error) {
+	return &MemberJoinRes{}, nil
+}
+
+func (c *Member) DeleteTeamMember(ctx context.Context, req *MemberLeaveReq) (res *MemberLeaveRes, err error) {
+	return &MemberLeaveRes{}, nil
+}
+
+func ExamplePrintRoutes() {
+	rs, err := handle.NameRoutes(&Member{}, handle.NameOption{Split: handle.SplitKebab})
+	if err != nil {
+		panic(err)
+	}
+	_ = handle.PrintRoutes(os.Stdout, rs)
+
+	// Output:
+	// DELETE  /team-member            Member.DeleteTeamMember
+	// GET     /user/:id               Member.GetUserBy
+	// POST    /user/:id/team/:teamId  Member.PostUserByTeamBy
+}
+
+type Unknown struct{}
+
+func (c Unknown) FetchUser(ctx context.Context, req *MemberGetReq) (res *MemberGetRes, err error) {
+	return nil, nil
+}
+
+func (c Unknown) GetByBy(ctx context.Context, req *MemberGetReq) (res *MemberGetRes, err error) {
+	return nil, nil
+}
+
+func TestRegisterByName(t *testing.T) {
+	r := gin.New()
+	if err := handle.RegisterByName(r.Group("/v1"), &Member{}, handle.NameOption{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, route := range r.Routes() {
+		paths = append(paths, route.Method+" "+route.Path)
+	}
+	got := strings.Join(paths, ", ")
+	for _, want := range []string{"GET /v1/user/:id", "POST /v1/user/:id/team/:teamId", "DELETE /v1/team/member"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("routes %q should contain %q", got, want)
+		}
+	}
+
+	err := handle.RegisterByName(r, Unknown{}, handle.NameOption{})
+	if err == nil {
+		t.Fatal("want error")
+	}
+	for _, want := range []string{
+		`Unknown.FetchUser: method name "FetchUser" must start with one of GET/POST/PUT/PATCH/DELETE/HEAD/OPTIONS`,
+		`Unknown.GetByBy: method name "GetByBy" has more "By" segments than uri fields`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should contain %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package handle
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// NamingPolicy 请求参数与返回参数的命名规范，在构造 ReqResFunc 时校验，
+// 违反规范时返回 RuleRequestName 或 RuleResultName 的 *SignatureError
+//
+// t 为结构体或结构体指针；切片、map 类型的返回值不会校验
+type NamingPolicy interface {
+	CheckRequest(t reflect.Type) error
+	CheckResult(t reflect.Type) error
+}
+
+// WithNaming 设置命名规范，默认为 SuffixNaming("Req", "Res")：
+//
+//	handle.NewReqResFunc(fn, handle.WithNaming(handle.NamingOff))
+//	handle.RegisterObject(r, controller.User, handle.WithNaming(handle.MarkerNaming))
+//
+// 只在构造 ReqResFunc 时生效，即 NewReqResFunc、ObjectHandler、RegisterObject、RegisterByName，
+// 也可以通过 SetDefaultOptions 设置全局的命名规范
+func WithNaming(policy NamingPolicy) Option {
+	return func(o *options) { o.naming = policy }
+}
+
+// NamingOff 不校验命名
+var NamingOff NamingPolicy = namingOff{}
+
+type namingOff struct{}
+
+func (namingOff) CheckRequest(reflect.Type) error { return nil }
+func (namingOff) CheckResult(reflect.Type) error  { return nil }
+
+// SuffixNaming 要求结构体名以 request、result 结尾，为空时不校验
+func SuffixNaming(request, result string) NamingPolicy {
+	return suffixNaming{request: request, result: result}
+}
+
+type suffixNaming struct{ request, result string }
+
+func (n suffixNaming) CheckRequest(t reflect.Type) error {
+	return checkSuffix(t, n.request, "request")
+}
+
+func (n suffixNaming) CheckResult(t reflect.Type) error {
+	return checkSuffix(t, n.result, "result")
+}
+
+func checkSuffix(t reflect.Type, suffix, kind string) error {
+	if suffix == "" || strings.HasSuffix(structOf(t).Name(), suffix) {
+		return nil
+	}
+	return fmt.Errorf(`%s struct should be named with %q suffix like "Xxx%s" or "*Xxx%s"`, kind, suffix, suffix, suffix)
+}
+
+// RegexNaming 要求结构体名匹配正则表达式，为 nil 时不校验
+func RegexNaming(request, result *regexp.Regexp) NamingPolicy {
+	return regexNaming{request: request, result: result}
+}
+
+type regexNaming struct{ request, result *regexp.Regexp }
+
+func (n regexNaming) CheckRequest(t reflect.Type) error {
+	return checkRegex(t, n.request, "request")
+}
+
+func (n regexNaming) CheckResult(t reflect.Type) error {
+	return checkRegex(t, n.result, "result")
+}
+
+func checkRegex(t reflect.Type, re *regexp.Regexp, kind string) error {
+	if re == nil || re.MatchString(structOf(t).Name()) {
+		return nil
+	}
+	return fmt.Errorf("%s struct name should match %s", kind, re)
+}
+
+// RequestMarker 与 ResultMarker 是 MarkerNaming 使用的标记接口，值或指针实现均可：
+//
+//	type UserQuery struct{ ... }
+//
+//	func (UserQuery) IsRequest() {}
+type (
+	RequestMarker interface{ IsRequest() }
+	ResultMarker  interface{ IsResult() }
+)
+
+// MarkerNaming 不限制结构体名，要求请求参数实现 RequestMarker、返回参数实现 ResultMarker
+var MarkerNaming NamingPolicy = markerNaming{}
+
+type markerNaming struct{}
+
+func (markerNaming) CheckRequest(t reflect.Type) error {
+	if implements(t, reflect.TypeOf((*RequestMarker)(nil)).Elem()) {
+		return nil
+	}
+	return fmt.Errorf("request struct should implement handle.RequestMarker: IsRequest()")
+}
+
+func (markerNaming) CheckResult(t reflect.Type) error {
+	if implements(t, reflect.TypeOf((*ResultMarker)(nil)).Elem()) {
+		return nil
+	}
+	return fmt.Errorf("result struct should implement handle.ResultMarker: IsResult()")
+}
+
+// implements 结构体或其指针是否实现了接口 iface
+func implements(t, iface reflect.Type) bool {
+	t = structOf(t)
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// structOf 返回指针指向的结构体类型
+func structOf(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
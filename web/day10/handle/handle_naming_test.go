@@ -0,0 +1,92 @@
+package handle_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	UserQuery struct {
+		meta struct{} `method:"GET" path:"/users/:id"`
+		Id   int      `uri:"id"`
+	}
+	UserView struct {
+		Id int `json:"id"`
+	}
+)
+
+func (UserQuery) IsRequest() {}
+func (*UserView) IsResult()  {}
+
+type Users struct{}
+
+func (Users) Get(ctx context.Context, req *UserQuery) (*UserView, error) {
+	return &UserView{Id: req.Id}, nil
+}
+
+func TestNamingPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy handle.NamingPolicy
+		fn     any
+		rules  []handle.Rule
+	}{
+		{"default", nil, Users{}.Get, []handle.Rule{handle.RuleRequestName, handle.RuleResultName}},
+		{"default suffix", nil, Echo, nil},
+		{"off", handle.NamingOff, Users{}.Get, nil},
+		{"suffix", handle.SuffixNaming("Query", "View"), Users{}.Get, nil},
+		{"suffix request only", handle.SuffixNaming("Query", ""), Echo, []handle.Rule{handle.RuleRequestName}},
+		{"regex", handle.RegexNaming(regexp.MustCompile(`^User`), regexp.MustCompile(`View$`)), Users{}.Get, nil},
+		{"regex mismatch", handle.RegexNaming(regexp.MustCompile(`Req$`), nil), Users{}.Get, []handle.Rule{handle.RuleRequestName}},
+		{"marker", handle.MarkerNaming, Users{}.Get, nil},
+		{"marker missing", handle.MarkerNaming, Echo, []handle.Rule{handle.RuleRequestName, handle.RuleResultName}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []handle.Option
+			if tt.policy != nil {
+				opts = append(opts, handle.WithNaming(tt.policy))
+			}
+			_, err := handle.TryNewReqResFunc(tt.fn, opts...)
+
+			var rules []handle.Rule
+			if err != nil {
+				for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+					var se *handle.SignatureError
+					if errors.As(e, &se) {
+						rules = append(rules, se.Rule)
+					}
+				}
+			}
+			if !reflect.DeepEqual(rules, tt.rules) {
+				t.Errorf("rules = %v, want %v (err = %v)", rules, tt.rules, err)
+			}
+		})
+	}
+}
+
+func TestNamingMessage(t *testing.T) {
+	_, err := handle.TryNewReqResFunc(Users{}.Get)
+	if err == nil || !strings.Contains(err.Error(), `request struct should be named with "Req" suffix`) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestNamingPerRegistration(t *testing.T) {
+	r := gin.New()
+	if err := handle.RegisterObject(r, Users{}); err == nil {
+		t.Error("RegisterObject with default naming = nil, want error")
+	}
+	if err := handle.RegisterObject(r, Users{}, handle.WithNaming(handle.MarkerNaming)); err != nil {
+		t.Errorf("RegisterObject with MarkerNaming = %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package handle
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// Format 响应体的编码格式
+type Format string
+
+const (
+	FormatJSON     Format = "json"
+	FormatXML      Format = "xml"
+	FormatYAML     Format = "yaml"
+	FormatMsgPack  Format = "msgpack"
+	FormatProtobuf Format = "protobuf" // 只有返回值为 proto.Message 时可用，且不会被 Envelope 包装
+)
+
+// DefaultFormats 默认支持的编码格式，Accept 为空或 */* 时使用第一个；
+// 只有 JSON，浏览器的 Accept 包含 application/xml，默认启用 XML 会返回字段名未经 json 标签重命名的 XML，
+// 其他格式通过 WithFormats 或 meta 标签 produces 按需开启
+var DefaultFormats = []Format{FormatJSON}
+
+type formatter struct {
+	mimes       []string // 可以匹配的 MIME 类型
+	contentType string   // 响应头 Content-Type
+	marshal     func(body any) ([]byte, error)
+	accepts     func(res reflect.Type) bool // 能否编码该类型的返回参数，为 nil 时不限
+}
+
+var formatters = map[Format]formatter{
+	FormatJSON: {
+		mimes:       []string{"application/json"},
+		contentType: "application/json; charset=utf-8",
+		marshal:     json.Marshal,
+	},
+	FormatXML: {
+		mimes:       []string{"application/xml", "text/xml"},
+		contentType: "application/xml; charset=utf-8",
+		marshal:     xml.Marshal,
+		accepts: func(res reflect.Type) bool {
+			// encoding/xml 不支持 map
+			for res != nil && (res.Kind() == reflect.Pointer || res.Kind() == reflect.Slice || res.Kind() == reflect.Array) {
+				res = res.Elem()
+			}
+			return res == nil || res.Kind() != reflect.Map
+		},
+	},
+	FormatYAML: {
+		mimes:       []string{"application/yaml", "application/x-yaml", "text/yaml"},
+		contentType: "application/yaml; charset=utf-8",
+		marshal:     yaml.Marshal,
+	},
+	FormatMsgPack: {
+		mimes:       []string{"application/msgpack", "application/x-msgpack"},
+		contentType: "application/msgpack",
+		marshal: func(body any) ([]byte, error) {
+			var b []byte
+			err := codec.NewEncoderBytes(&b, new(codec.MsgpackHandle)).Encode(body)
+			return b, err
+		},
+	},
+	FormatProtobuf: {
+		mimes:       []string{"application/x-protobuf", "application/protobuf"},
+		contentType: "application/x-protobuf",
+		marshal: func(body any) ([]byte, error) {
+			// Envelope 包装后的返回值不是 proto.Message，只编码其中的数据
+			if resp, ok := body.(Response); ok {
+				body = resp.Data
+			}
+			msg, ok := body.(proto.Message)
+			if !ok {
+				return nil, fmt.Errorf("%T is not a proto.Message", body)
+			}
+			return proto.Marshal(msg)
+		},
+		accepts: func(res reflect.Type) bool { return res != nil && res.Implements(protoMessageType) },
+	},
+}
+
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+// staticRes 是否在构造时就能确定返回值的类型，返回参数为接口时取决于运行时的值
+func (o *options) staticRes() bool {
+	return o.resKnown && (o.res == nil || o.res.Kind() != reflect.Interface)
+}
+
+// encodable 返回能够编码返回参数的格式，返回参数的类型不确定时不做限制
+func (o *options) encodable() []Format {
+	if !o.staticRes() {
+		return o.formats
+	}
+	var formats []Format
+	for _, format := range o.formats {
+		if accepts := formatters[format].accepts; accepts == nil || accepts(o.res) {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+// WithFormats 设置路由支持的编码格式，按优先级从高到低排列，默认为 DefaultFormats；
+// 也可以在 meta 标签中声明：`produces:"json,xml"`
+func WithFormats(formats ...Format) Option {
+	return func(o *options) { o.formats = formats }
+}
+
+// parseFormats 解析 meta 标签中的 produces
+func parseFormats(tag string) ([]Format, error) {
+	var formats []Format
+	for _, name := range splitTag(tag) {
+		format := Format(strings.ToLower(name))
+		if _, ok := formatters[format]; !ok {
+			return nil, fmt.Errorf("unknown format %q", name)
+		}
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
+
+// negotiate 根据 Accept 返回可用的编码格式，按客户端的偏好排序
+func negotiate(accept string, formats []Format) []Format {
+	if strings.TrimSpace(accept) == "" {
+		return formats
+	}
+
+	type weighted struct {
+		format Format
+		q      float64
+		order  int
+	}
+
+	best := map[Format]weighted{}
+	for i, part := range strings.Split(accept, ",") {
+		mime, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		mime = strings.ToLower(strings.TrimSpace(mime))
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		for j, format := range formats {
+			if !matchMime(mime, formatters[format].mimes) {
+				continue
+			}
+			// 通配符按路由声明的顺序排列
+			order := i*len(formats) + j
+			if w, ok := best[format]; !ok || q > w.q || (q == w.q && order < w.order) {
+				best[format] = weighted{format: format, q: q, order: order}
+			}
+		}
+	}
+
+	ws := make([]weighted, 0, len(best))
+	for _, w := range best {
+		ws = append(ws, w)
+	}
+	sort.Slice(ws, func(i, j int) bool {
+		if ws[i].q != ws[j].q {
+			return ws[i].q > ws[j].q
+		}
+		return ws[i].order < ws[j].order
+	})
+
+	accepted := make([]Format, len(ws))
+	for i, w := range ws {
+		accepted[i] = w.format
+	}
+	return accepted
+}
+
+func matchMime(pattern string, mimes []string) bool {
+	if pattern == "*/*" {
+		return true
+	}
+	for _, mime := range mimes {
+		if pattern == mime {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(mime, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+var errNotAcceptable = NewError(CodeNotAcceptable, http.StatusNotAcceptable, "")
+
+// negotiatedEncoder 返回按 accepted 依次尝试编码的 Encoder，都无法编码时返回 406，错误则以 JSON 返回
+func negotiatedEncoder(accepted []Format, envelope Envelope) Encoder {
+	return func(c *gin.Context, status int, body any) {
+		var errs []error
+		for _, format := range accepted {
+			f := formatters[format]
+			data, err := f.marshal(body)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", format, err))
+				continue
+			}
+			c.Data(status, f.contentType, data)
+			return
+		}
+
+		_ = c.Error(errors.Join(errs...))
+
+		// 错误的响应体无法编码时，如 protobuf，使用 JSON 返回，避免错误被 406 覆盖
+		if status >= http.StatusBadRequest {
+			JSONEncoder(c, status, body)
+			return
+		}
+		notAcceptable(c, envelope)
+	}
+}
+
+// notAcceptable 以 JSON 格式返回 406
+func notAcceptable(c *gin.Context, envelope Envelope) {
+	status, body := envelope.Failure(c, localize(c, errNotAcceptable))
+	JSONEncoder(c, status, body)
+}
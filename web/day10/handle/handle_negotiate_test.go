@@ -0,0 +1,147 @@
+package handle_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type GreetReq struct{}
+
+type ProducesReq struct {
+	meta struct{} `produces:"xml,json"`
+	Name string   `form:"name"`
+}
+
+// allFormats 开启所有编码格式，DefaultFormats 只有 JSON
+var allFormats = handle.WithFormats(handle.FormatJSON, handle.FormatXML, handle.FormatYAML, handle.FormatMsgPack, handle.FormatProtobuf)
+
+func Greet(ctx context.Context, req *GreetReq) (res *wrapperspb.StringValue, err error) {
+	return wrapperspb.String("hello"), nil
+}
+
+func TestNegotiate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/echo/:id", handle.Typed(Echo).Handler(allFormats))
+	r.GET("/json-only/:id", handle.Typed(Echo).Handler(allFormats, handle.WithFormats(handle.FormatJSON)))
+	r.GET("/default/:id", handle.Typed(Echo).Handler())
+	r.GET("/produces", handle.NewReqResFunc(func(ctx context.Context, req *ProducesReq) (*EchoRes, error) {
+		return &EchoRes{Name: req.Name}, nil
+	}).Handler())
+	r.GET("/greet", handle.Typed(Greet).Handler(allFormats))
+	r.GET("/fail", handle.Handle(func(ctx context.Context, decode func(point any) error) (any, error) {
+		return nil, handle.NewError(handle.CodeForbidden, http.StatusForbidden, "no permission")
+	}, allFormats))
+
+	tests := []struct {
+		target, accept string
+		status         int
+		contentType    string
+		want           string
+	}{
+		{"/echo/1?name=gee", "", 200, "application/json", `{"code":200,"msg":"","data":{"id":1,"name":"gee"}}`},
+		{"/echo/1?name=gee", "*/*", 200, "application/json", `{"code":200,"msg":"","data":{"id":1,"name":"gee"}}`},
+		{"/echo/1?name=gee", "application/json;q=0.5, application/xml", 200, "application/xml", `<Response><code>200</code><msg></msg><data><Id>1</Id><Name>gee</Name></data></Response>`},
+		{"/echo/1?name=gee", "application/x-yaml", 200, "application/yaml", "code: 200\nmsg: \"\"\ndata:\n    id: 1\n    name: gee\n"},
+		{"/echo/1?name=gee", "text/html", 406, "application/json", `{"code":406,"msg":"Not Acceptable","data":null}`},
+		{"/echo/1?name=gee", "application/x-protobuf", 406, "application/json", `{"code":406,"msg":"Not Acceptable","data":null}`},
+		{"/echo/1?name=gee", "application/x-protobuf, application/json;q=0.1", 200, "application/json", `{"code":200,"msg":"","data":{"id":1,"name":"gee"}}`},
+		{"/json-only/1", "application/xml", 406, "application/json", `{"code":406,"msg":"Not Acceptable","data":null}`},
+		// 默认只有 JSON，浏览器的 Accept 不会得到 XML
+		{"/default/1?name=gee", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", 200, "application/json", `{"code":200,"msg":"","data":{"id":1,"name":"gee"}}`},
+		{"/default/1?name=gee", "application/xml", 406, "application/json", `{"code":406,"msg":"Not Acceptable","data":null}`},
+		// meta 标签 produces 不依赖 RegisterObject
+		{"/produces?name=gee", "", 200, "application/xml", `<Response><code>200</code><msg></msg><data><Id>0</Id><Name>gee</Name></data></Response>`},
+		{"/fail", "application/x-protobuf", 403, "application/json", `{"code":403,"msg":"no permission","data":null}`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		req.Header.Set("Accept", tt.accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status || !strings.HasPrefix(w.Header().Get("Content-Type"), tt.contentType) || w.Body.String() != tt.want {
+			t.Errorf("%s (%s): got %d %s %q, want %d %s %q", tt.target, tt.accept, w.Code, w.Header().Get("Content-Type"), w.Body, tt.status, tt.contentType, tt.want)
+		}
+	}
+
+	t.Run("msgpack", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/echo/1?name=gee", nil)
+		req.Header.Set("Accept", "application/msgpack")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var resp struct {
+			Code int     `codec:"code"`
+			Data EchoRes `codec:"data"`
+		}
+		if err := codec.NewDecoderBytes(w.Body.Bytes(), new(codec.MsgpackHandle)).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Code != handle.CodeOK || resp.Data != (EchoRes{Id: 1, Name: "gee"}) {
+			t.Errorf("got %+v", resp)
+		}
+	})
+
+	t.Run("protobuf", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/greet", nil)
+		req.Header.Set("Accept", "application/x-protobuf")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var msg wrapperspb.StringValue
+		if err := proto.Unmarshal(w.Body.Bytes(), &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.GetValue() != "hello" {
+			t.Errorf("got %q", msg.GetValue())
+		}
+	})
+}
+
+func TestNegotiateResType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var errs []string
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Next()
+		errs = append(errs, c.Errors.Errors()...)
+	})
+	r.GET("/echo/:id", handle.Typed(Echo).Handler(allFormats))
+	r.GET("/counts", handle.NewReqResFunc(func(ctx context.Context) (map[string]int, error) {
+		return map[string]int{"a": 1}, nil
+	}).Handler(allFormats))
+
+	// 构造时就排除了无法编码返回参数的格式，不会先尝试编码、失败后再回退或返回 406
+	tests := []struct {
+		target, accept string
+		status         int
+		want           string
+	}{
+		{"/echo/1?name=gee", "application/x-protobuf", 406, `{"code":406,"msg":"Not Acceptable","data":null}`}, // 返回参数不是 proto.Message
+		{"/echo/1?name=gee", "application/x-protobuf, application/json;q=0.1", 200, `{"code":200,"msg":"","data":{"id":1,"name":"gee"}}`},
+		{"/counts", "application/xml", 406, `{"code":406,"msg":"Not Acceptable","data":null}`}, // encoding/xml 不支持 map
+		{"/counts", "application/xml, application/json;q=0.1", 200, `{"code":200,"msg":"","data":{"a":1}}`},
+	}
+	for _, tt := range tests {
+		errs = nil
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		req.Header.Set("Accept", tt.accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.status || w.Body.String() != tt.want || len(errs) > 0 {
+			t.Errorf("%s (%s): got %d %q, errors %v, want %d %q", tt.target, tt.accept, w.Code, w.Body, errs, tt.status, tt.want)
+		}
+	}
+}
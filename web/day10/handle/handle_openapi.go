@@ -0,0 +1,477 @@
+package handle
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIInfo 文档的基本信息
+type OpenAPIInfo struct {
+	Title       string `json:"title" yaml:"title"`
+	Version     string `json:"version" yaml:"version"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// OpenAPIDoc OpenAPI 3.1 文档，只包含 handle 用到的部分
+type OpenAPIDoc struct {
+	OpenAPI    string                                  `json:"openapi" yaml:"openapi"`
+	Info       OpenAPIInfo                             `json:"info" yaml:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths" yaml:"paths"`
+	Components OpenAPIComponents                       `json:"components" yaml:"components"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas" yaml:"schemas"`
+}
+
+type OpenAPIOperation struct {
+	OperationId string                      `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Tags        []string                    `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *OpenAPIBody                `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses" yaml:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name     string         `json:"name" yaml:"name"`
+	In       string         `json:"in" yaml:"in"` // path, query, header, cookie
+	Required bool           `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema   *OpenAPISchema `json:"schema" yaml:"schema"`
+}
+
+type OpenAPIBody struct {
+	Required bool                     `json:"required,omitempty" yaml:"required,omitempty"`
+	Content  map[string]*OpenAPIMedia `json:"content" yaml:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                    `json:"description" yaml:"description"`
+	Headers     map[string]*OpenAPIHeader `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Content     map[string]*OpenAPIMedia  `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type OpenAPIHeader struct {
+	Schema *OpenAPISchema `json:"schema" yaml:"schema"`
+}
+
+type OpenAPIMedia struct {
+	Schema *OpenAPISchema `json:"schema" yaml:"schema"`
+}
+
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string                    `json:"format,omitempty" yaml:"format,omitempty"`
+	Description          string                    `json:"description,omitempty" yaml:"description,omitempty"`
+	Enum                 []any                     `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Default              any                       `json:"default,omitempty" yaml:"default,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// MountOpenAPI 在 router 上挂载 OpenAPI 文档，如 path 为 "/openapi" 时：
+//
+//	GET /openapi.json
+//	GET /openapi.yaml
+//
+// 文档在每次请求时根据 Routes() 生成，因此挂载之后注册的路由也会出现在文档中
+func MountOpenAPI(router gin.IRouter, path string, info OpenAPIInfo) {
+	path = strings.TrimSuffix(path, "/")
+
+	router.GET(path+".json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, OpenAPI(info, Routes()))
+	})
+	router.GET(path+".yaml", func(c *gin.Context) {
+		data, err := yaml.Marshal(OpenAPI(info, Routes()))
+		if err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+			return
+		}
+		c.Data(http.StatusOK, "application/yaml; charset=utf-8", data)
+	})
+}
+
+// OpenAPI 根据路由表生成 OpenAPI 3.1 文档
+//
+// 请求参数的 in 标签决定参数的位置；没有 in 标签时，uri 标签生成 path 参数，
+// GET、HEAD、DELETE、OPTIONS 请求的其余字段生成 query 参数，字段名取自 form 标签；其他请求的其余字段生成 JSON 请求体，
+// 包含上传的文件时生成 multipart/form-data 请求体，字段名取自 form 标签。
+// 返回值包装在 Response{code,msg,data} 中，并列出已注册的错误映射对应的业务代码
+func OpenAPI(info OpenAPIInfo, rs []Route) *OpenAPIDoc {
+	g := &openAPIGenerator{schemas: map[string]*OpenAPISchema{}}
+	doc := &OpenAPIDoc{
+		OpenAPI:    "3.1.0",
+		Info:       info,
+		Paths:      map[string]map[string]*OpenAPIOperation{},
+		Components: OpenAPIComponents{Schemas: g.schemas},
+	}
+
+	errs := errorResponses()
+	for _, route := range rs {
+		p := openAPIPath(route.FullPath)
+		if p == "" {
+			p = openAPIPath(route.Path)
+		}
+		if doc.Paths[p] == nil {
+			doc.Paths[p] = map[string]*OpenAPIOperation{}
+		}
+		doc.Paths[p][strings.ToLower(route.Method)] = g.operation(route, errs)
+	}
+	return doc
+}
+
+type openAPIGenerator struct {
+	schemas map[string]*OpenAPISchema // components.schemas
+}
+
+func (g *openAPIGenerator) operation(route Route, errs map[string]*OpenAPIResponse) *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		OperationId: route.Name,
+		Summary:     route.Summary,
+		Tags:        route.Tags,
+		Responses:   map[string]*OpenAPIResponse{},
+	}
+
+	body := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	inQuery := bodyless(route.Method)
+	fields := requestFields(route.Func.Req())
+	multipart := slices.ContainsFunc(fields, func(field reflect.StructField) bool { return isFile(field.Type) })
+	for _, field := range fields {
+		schema := g.schema(field.Type)
+		if value, ok := field.Tag.Lookup("default"); ok {
+			schema.Default = defaultValue(schema, value)
+		}
+		in := field.Tag.Get("in")
+		switch {
+		case in == InHeader || in == InCookie || in == InQuery:
+			op.Parameters = append(op.Parameters, &OpenAPIParameter{Name: sourceName(field, in), In: in, Required: required(field), Schema: schema})
+		case in == InPath:
+			op.Parameters = append(op.Parameters, &OpenAPIParameter{Name: sourceName(field, in), In: in, Required: true, Schema: schema})
+		case in == InBody:
+			name := sourceName(field, in)
+			body.Properties[name] = schema
+			if required(field) {
+				body.Required = append(body.Required, name)
+			}
+		case field.Tag.Get("uri") != "":
+			op.Parameters = append(op.Parameters, &OpenAPIParameter{Name: tagName(field, "uri"), In: "path", Required: true, Schema: schema})
+		case inQuery:
+			if field.Tag.Get("form") == "-" {
+				continue
+			}
+			op.Parameters = append(op.Parameters, &OpenAPIParameter{Name: tagName(field, "form"), In: "query", Required: required(field), Schema: schema})
+		case multipart:
+			if field.Tag.Get("form") == "-" {
+				continue
+			}
+			name := tagName(field, "form")
+			body.Properties[name] = schema
+			if required(field) {
+				body.Required = append(body.Required, name)
+			}
+		default:
+			if field.Tag.Get("json") == "-" {
+				continue
+			}
+			name := tagName(field, "json")
+			body.Properties[name] = schema
+			if required(field) {
+				body.Required = append(body.Required, name)
+			}
+		}
+	}
+	if len(body.Properties) > 0 {
+		op.RequestBody = &OpenAPIBody{Required: true, Content: jsonContent(body)}
+		if multipart {
+			op.RequestBody.Content = map[string]*OpenAPIMedia{"multipart/form-data": {Schema: body}}
+		}
+	}
+
+	op.Responses["200"] = &OpenAPIResponse{
+		Description: http.StatusText(http.StatusOK),
+		Content:     jsonContent(envelopeSchema(&OpenAPISchema{Type: "integer", Enum: []any{CodeOK}}, g.schema(route.Func.Res()))),
+	}
+	if res := route.Func.Res(); res != nil && isResponder(res) {
+		// Responder 自行写入响应，格式未知
+		op.Responses["200"].Content = map[string]*OpenAPIMedia{"*/*": {Schema: &OpenAPISchema{Type: "string", Format: "binary"}}}
+	} else if res != nil {
+		op.Responses["200"].Headers = g.outHeaders(res)
+	}
+	for status, resp := range errs {
+		op.Responses[status] = resp
+	}
+	return op
+}
+
+// errorResponses 根据已注册的错误映射，生成错误响应，相同 HTTP 状态码的业务代码合并在一起
+func errorResponses() map[string]*OpenAPIResponse {
+	codes := map[int][]int{
+		http.StatusBadRequest:          {CodeBadRequest},
+		http.StatusInternalServerError: {CodeInternal},
+	}
+
+	mappingsMu.RLock()
+	for _, m := range errorMappings {
+		if !slices.Contains(codes[m.status], m.code) {
+			codes[m.status] = append(codes[m.status], m.code)
+		}
+	}
+	mappingsMu.RUnlock()
+
+	resps := map[string]*OpenAPIResponse{}
+	for status, cs := range codes {
+		slices.Sort(cs)
+		enum := make([]any, len(cs))
+		for i, c := range cs {
+			enum[i] = c
+		}
+		resps[fmt.Sprint(status)] = &OpenAPIResponse{
+			Description: http.StatusText(status),
+			Content:     jsonContent(envelopeSchema(&OpenAPISchema{Type: "integer", Enum: enum}, &OpenAPISchema{})),
+		}
+	}
+	return resps
+}
+
+// schema 返回类型 t 的 Schema，具名结构体会注册到 components.schemas 并返回引用
+func (g *openAPIGenerator) schema(t reflect.Type) *OpenAPISchema {
+	if t == nil {
+		return &OpenAPISchema{Type: "null"}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case reflect.TypeOf(time.Duration(0)):
+		return &OpenAPISchema{Type: "string", Description: "duration, e.g. 1h30m"}
+	case uploadType, fileHeaderType:
+		return &OpenAPISchema{Type: "string", Format: "binary"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+
+		name := schemaName(t)
+		if _, ok := g.schemas[name]; !ok {
+			g.schemas[name] = &OpenAPISchema{} // 占位，避免递归类型无限展开
+			*g.schemas[name] = *g.structSchema(t)
+		}
+		return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+	}
+	return &OpenAPISchema{}
+}
+
+func (g *openAPIGenerator) structSchema(t reflect.Type) *OpenAPISchema {
+	schema := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	for _, field := range jsonFields(t) {
+		name := tagName(field, "json")
+		schema.Properties[name] = g.schema(field.Type)
+		if required(field) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// outHeaders 返回返回参数中 out:"header" 与 out:"cookie" 字段对应的响应头
+func (g *openAPIGenerator) outHeaders(t reflect.Type) map[string]*OpenAPIHeader {
+	t = structOf(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	fields, err := outFields(t)
+	if err != nil {
+		return nil
+	}
+
+	headers := map[string]*OpenAPIHeader{}
+	for _, of := range fields {
+		switch of.out {
+		case OutHeader:
+			headers[of.name] = &OpenAPIHeader{Schema: g.schema(t.FieldByIndex(of.index).Type)}
+		case OutCookie:
+			headers["Set-Cookie"] = &OpenAPIHeader{Schema: &OpenAPISchema{Type: "string"}}
+		}
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// jsonFields 按 encoding/json 的规则返回结构体会被序列化的字段，匿名结构体字段会被展开
+func jsonFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get("json") == "-" {
+			continue
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && ft.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			fields = append(fields, jsonFields(ft)...)
+			continue
+		}
+		if field.IsExported() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// requestFields 返回请求参数中可导出的字段，匿名结构体字段会被展开，meta 字段会被忽略
+func requestFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	if t == nil {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		ft := field.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && ft.Kind() == reflect.Struct {
+			fields = append(fields, requestFields(ft)...)
+			continue
+		}
+		if field.IsExported() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// tagName 返回字段在标签 key 中的名称，缺省为字段名
+func tagName(field reflect.StructField, key string) string {
+	if name, _, _ := strings.Cut(field.Tag.Get(key), ","); name != "" {
+		return name
+	}
+	return field.Name
+}
+
+// required 字段是否为必填，读取 binding 与 validate 标签
+func required(field reflect.StructField) bool {
+	for _, key := range []string{"binding", "validate"} {
+		for _, rule := range strings.Split(field.Tag.Get(key), ",") {
+			if rule == "required" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func bodyless(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func envelopeSchema(code, data *OpenAPISchema) *OpenAPISchema {
+	return &OpenAPISchema{
+		Type: "object",
+		Properties: map[string]*OpenAPISchema{
+			"code": code,
+			"msg":  {Type: "string"},
+			"data": data,
+
+			"requestId": {Type: "string"},
+		},
+		Required: []string{"code", "msg", "data"},
+	}
+}
+
+func jsonContent(schema *OpenAPISchema) map[string]*OpenAPIMedia {
+	return map[string]*OpenAPIMedia{"application/json": {Schema: schema}}
+}
+
+var (
+	pathParam     = regexp.MustCompile(`[:*]([^/]+)`)
+	invalidSchema = regexp.MustCompile(`[^a-zA-Z0-9.\-_]`)
+)
+
+// openAPIPath 将 gin 的路由参数转换为 OpenAPI 格式，如 /user/:id -> /user/{id}
+func openAPIPath(p string) string {
+	return pathParam.ReplaceAllString(p, "{$1}")
+}
+
+// schemaName 返回类型在 components.schemas 中的名称，如 service.UserGetRes
+func schemaName(t reflect.Type) string {
+	return invalidSchema.ReplaceAllString(t.String(), "_")
+}
+
+// defaultValue 将 default 标签转换为文档中的默认值，数字与布尔值按 JSON 解析，
+// 数组与对象与 applyDefaults 一样按逗号拆分，如 "a,b" 为 ["a","b"]、"a=1" 为 {"a":1}
+func defaultValue(schema *OpenAPISchema, value string) any {
+	switch schema.Type {
+	case "integer", "number", "boolean":
+		var v any
+		if err := json.Unmarshal([]byte(value), &v); err == nil {
+			return v
+		}
+	case "array":
+		if schema.Items != nil {
+			items := []any{}
+			for _, item := range splitValues([]string{value}, ",") {
+				items = append(items, defaultValue(schema.Items, item))
+			}
+			return items
+		}
+	case "object":
+		if schema.AdditionalProperties != nil {
+			m := map[string]any{}
+			for _, item := range splitValues([]string{value}, ",") {
+				if k, v, ok := strings.Cut(item, "="); ok {
+					m[k] = defaultValue(schema.AdditionalProperties, v)
+				}
+			}
+			return m
+		}
+	}
+	return value
+}
@@ -0,0 +1,109 @@
+package handle_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	CommentCreateReq struct {
+		ArticleId int    `uri:"articleId" json:"-"`
+		Content   string `json:"content" binding:"required"`
+		Tags      []string
+	}
+	CommentCreateRes struct {
+		Id      int          `json:"id"`
+		Replies []CommentRes `json:"replies"`
+	}
+	CommentRes struct {
+		Id      int          `json:"id"`
+		Replies []CommentRes `json:"replies"` // 递归类型
+	}
+)
+
+func CreateComment(ctx context.Context, req *CommentCreateReq) (res *CommentCreateRes, err error) {
+	return &CommentCreateRes{}, nil
+}
+
+func TestMountOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	api := r.Group("/openapi-test")
+	err := handle.RegisterRoutes(api,
+		handle.Route{Method: http.MethodGet, Path: "/echo/:id", Summary: "回显", Tags: []string{"echo"}, Func: handle.Typed(Echo)},
+		handle.Route{Method: http.MethodPost, Path: "/article/:articleId/comment", Func: handle.NewReqResFunc(CreateComment)},
+		handle.Route{Method: http.MethodPost, Path: "/users", Func: handle.NewReqResFunc(func(ctx context.Context, req *UserCreateReq) (*UserCreateRes, error) {
+			return &UserCreateRes{}, nil
+		})},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handle.MountOpenAPI(r, "/openapi", handle.OpenAPIInfo{Title: "test", Version: "v1"})
+
+	var docs []handle.OpenAPIDoc
+	for _, target := range []string{"/openapi.json", "/openapi.yaml"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+
+		var doc handle.OpenAPIDoc
+		unmarshal := json.Unmarshal
+		if strings.HasSuffix(target, ".yaml") {
+			unmarshal = yaml.Unmarshal
+		}
+		if err := unmarshal(w.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		docs = append(docs, doc)
+	}
+
+	for _, doc := range docs {
+		get := doc.Paths["/openapi-test/echo/{id}"]["get"]
+		if get == nil || get.Summary != "回显" || len(get.Parameters) != 2 {
+			t.Fatalf("got get operation %+v", get)
+		}
+		if p := get.Parameters[0]; p.Name != "id" || p.In != "path" || !p.Required || p.Schema.Type != "integer" {
+			t.Errorf("got path parameter %+v", p)
+		}
+		if p := get.Parameters[1]; p.Name != "name" || p.In != "query" || p.Schema.Type != "string" {
+			t.Errorf("got query parameter %+v", p)
+		}
+
+		data := get.Responses["200"].Content["application/json"].Schema.Properties["data"]
+		if data.Ref != "#/components/schemas/handle_test.EchoRes" {
+			t.Errorf("got data schema %+v", data)
+		}
+		if code := get.Responses["500"].Content["application/json"].Schema.Properties["code"]; len(code.Enum) == 0 {
+			t.Errorf("got error code schema %+v", code)
+		}
+
+		post := doc.Paths["/openapi-test/article/{articleId}/comment"]["post"]
+		body := post.RequestBody.Content["application/json"].Schema
+		if _, ok := body.Properties["Tags"]; !ok || strings.Join(body.Required, ",") != "content" || len(post.Parameters) != 1 {
+			t.Errorf("got request body %+v", body)
+		}
+
+		created := doc.Paths["/openapi-test/users"]["post"].Responses["200"]
+		if h := created.Headers["Location"]; h == nil || h.Schema.Type != "string" || created.Headers["Set-Cookie"] == nil {
+			t.Errorf("got response headers %+v", created.Headers)
+		}
+		if user := doc.Components.Schemas["handle_test.UserCreateRes"]; len(user.Properties) != 3 || user.Properties["version"] == nil {
+			t.Errorf("got result schema without out fields %+v", user)
+		}
+
+		comment := doc.Components.Schemas["handle_test.CommentRes"]
+		if comment == nil || comment.Properties["replies"].Items.Ref != "#/components/schemas/handle_test.CommentRes" {
+			t.Errorf("got recursive schema %+v", comment)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package handle
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Option 构造 handler 的选项
+type Option func(o *options)
+
+type options struct {
+	envelope  Envelope           // 返回值的包装方式
+	encoder   Encoder            // 返回值的编码方式，为 nil 时根据 Accept 协商
+	formats   []Format           // 内容协商时支持的编码格式
+	decoders  map[string]Decoder // 请求体的解码器，key 为 MIME 类型
+	naming    NamingPolicy       // 请求参数与返回参数的命名规范
+	heartbeat time.Duration      // Server-Sent Events 的心跳间隔
+
+	pingInterval time.Duration              // WebSocket 发送 ping 的间隔
+	checkOrigin  func(r *http.Request) bool // WebSocket 升级连接时校验 Origin
+
+	uploadLimit int64    // 请求体的最大字节数，为 0 时不限制
+	uploadTypes []string // 上传文件允许的 MIME 类型，为空时不限制
+
+	interceptors []Interceptor // 处理函数的拦截器，在构造 ReqResFunc 时读取
+	debug        bool          // 调试模式，panic 的错误详情包含调用栈
+	timeout      time.Duration // 处理函数的超时时间，为 0 时不限制
+
+	res      reflect.Type // 返回参数的静态类型，见 withRes
+	resKnown bool         // 是否知道返回参数的静态类型，直接由 DecodeFunc 构造 handler 时为 false
+}
+
+var (
+	defaultsMu     sync.RWMutex
+	defaultOptions []Option
+)
+
+// SetDefaultOptions 设置全局默认选项，在之后构造的 handler 中生效，且优先级低于构造时传入的选项
+func SetDefaultOptions(opts ...Option) {
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
+
+	defaultOptions = append([]Option(nil), opts...)
+}
+
+// newOptions 依次应用默认值、全局默认选项与 opts
+func newOptions(opts []Option) *options {
+	o := &options{
+		envelope:  StandardEnvelope{},
+		formats:   DefaultFormats,
+		decoders:  defaultDecoders,
+		naming:    SuffixNaming("Req", "Res"),
+		heartbeat: 15 * time.Second,
+
+		pingInterval: 30 * time.Second,
+	}
+
+	defaultsMu.RLock()
+	for _, opt := range defaultOptions {
+		opt(o)
+	}
+	defaultsMu.RUnlock()
+
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithEnvelope 设置返回值的包装方式，默认为 StandardEnvelope
+func WithEnvelope(envelope Envelope) Option {
+	return func(o *options) { o.envelope = envelope }
+}
+
+// WithEncoder 设置返回值的编码方式，设置后不再根据 Accept 进行内容协商
+func WithEncoder(encoder Encoder) Option {
+	return func(o *options) { o.encoder = encoder }
+}
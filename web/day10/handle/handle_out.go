@@ -0,0 +1,179 @@
+package handle
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 返回参数的去向，通过 out 标签声明：
+//
+//	type UserCreateRes struct {
+//		Status   int          `out:"status" json:"-" xml:"-" yaml:"-"`                      // HTTP 状态码，为 0 时使用 Envelope 返回的状态码
+//		Location string       `out:"header" header:"Location" json:"-" xml:"-" yaml:"-"`    // 响应头，名称取自 header 标签，缺省为字段名
+//		ETag     string       `out:"header" header:"ETag" json:"-" xml:"-" yaml:"-"`
+//		Session  *http.Cookie `out:"cookie" json:"-" xml:"-" yaml:"-"`                      // Cookie，为 string 时名称取自 cookie 标签
+//		Id       int          `json:"id"`
+//	}
+//
+// 响应体原样编码返回参数，out 字段必须通过 json:"-"、xml:"-"、yaml:"-" 排除在响应体之外（msgpack 沿用 json 标签），
+// 注册时检查；零值的响应头与 Cookie 不会写入；Status 只在成功时生效，失败时仍由 Envelope.Failure 决定
+const (
+	OutHeader = "header" // 响应头，值为 string、[]string、time.Time（http.TimeFormat）或其他可以 fmt.Sprint 的类型
+	OutCookie = "cookie" // Cookie，值为 string、http.Cookie 或 *http.Cookie
+	OutStatus = "status" // HTTP 状态码，值为整数
+)
+
+// outField 声明了 out 标签的字段
+type outField struct {
+	index []int  // 字段的索引，匿名字段会展开
+	out   string // 去向
+	name  string // 响应头或 Cookie 的名称
+}
+
+var outsCache sync.Map // map[reflect.Type][]outField
+
+var cookieType = reflect.TypeOf(http.Cookie{})
+
+// bodyTags out 字段必须排除在响应体之外的标签
+var bodyTags = []string{"json", "xml", "yaml"}
+
+// outFields 解析并缓存结构体中声明了 out 标签的字段
+func outFields(t reflect.Type) ([]outField, error) {
+	if cached, ok := outsCache.Load(t); ok {
+		return cached.([]outField), nil
+	}
+
+	var fields []outField
+	var walk func(t reflect.Type, prefix []int) error
+	walk = func(t reflect.Type, prefix []int) error {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			index := append(append([]int(nil), prefix...), i)
+
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if field.Anonymous && ft.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+				if err := walk(ft, index); err != nil {
+					return err
+				}
+				continue
+			}
+			out, ok := field.Tag.Lookup("out")
+			if !ok || !field.IsExported() {
+				continue
+			}
+
+			of := outField{index: index, out: out}
+			name := t.String() + "." + field.Name
+			switch out {
+			case OutHeader:
+				of.name = tagName(field, "header")
+			case OutCookie:
+				of.name = tagName(field, "cookie")
+				if ft.Kind() != reflect.String && ft != cookieType {
+					return fmt.Errorf("out:%q field %s must be a string or http.Cookie", out, name)
+				}
+			case OutStatus:
+				switch field.Type.Kind() {
+				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				default:
+					return fmt.Errorf("out:%q field %s must be an integer", out, name)
+				}
+				if slices.ContainsFunc(fields, func(f outField) bool { return f.out == OutStatus }) {
+					return fmt.Errorf("duplicate out:%q field %s", out, name)
+				}
+			default:
+				return fmt.Errorf("invalid out tag %q of %s", out, name)
+			}
+			for _, key := range bodyTags {
+				if field.Tag.Get(key) != "-" {
+					return fmt.Errorf(`out:%q field %s must be excluded from the response body with json:"-" xml:"-" yaml:"-"`, out, name)
+				}
+			}
+			fields = append(fields, of)
+		}
+		return nil
+	}
+	if err := walk(t, nil); err != nil {
+		return nil, err
+	}
+
+	outsCache.Store(t, fields)
+	return fields, nil
+}
+
+// writeOut 将 data 中声明了 out 标签的字段写入响应头与 Cookie，返回 out:"status" 字段的状态码，没有时为 0
+func writeOut(c *gin.Context, data any) int {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return 0
+	}
+	fields, err := outFields(v.Type())
+	if err != nil {
+		return 0 // 签名校验时已经检查过 out 标签
+	}
+
+	status := 0
+	for _, of := range fields {
+		field, err := v.FieldByIndexErr(of.index)
+		if err != nil || field.IsZero() {
+			continue // 匿名结构体指针为 nil
+		}
+		switch of.out {
+		case OutStatus:
+			status = int(field.Int())
+		case OutHeader:
+			for _, value := range headerValues(field) {
+				c.Writer.Header().Add(of.name, value)
+			}
+		case OutCookie:
+			http.SetCookie(c.Writer, outCookie(field, of.name))
+		}
+	}
+	return status
+}
+
+// headerValues 返回响应头字段的值
+func headerValues(v reflect.Value) []string {
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	switch {
+	case v.Type() == timeType:
+		return []string{v.Interface().(time.Time).UTC().Format(http.TimeFormat)}
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8:
+		values := make([]string, v.Len())
+		for i := range values {
+			values[i] = strings.Join(headerValues(v.Index(i)), ", ")
+		}
+		return values
+	}
+	return []string{fmt.Sprint(v.Interface())}
+}
+
+// outCookie 返回 Cookie 字段对应的 http.Cookie，string 类型的 Cookie 作用于整个站点且禁止脚本读取
+func outCookie(v reflect.Value, name string) *http.Cookie {
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.String {
+		return &http.Cookie{Name: name, Value: v.String(), Path: "/", HttpOnly: true}
+	}
+	cookie := v.Interface().(http.Cookie)
+	if cookie.Name == "" {
+		cookie.Name = name
+	}
+	return &cookie
+}
@@ -0,0 +1,151 @@
+package handle_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserCreateReq struct {
+	Name string `json:"name"`
+}
+
+type Audit struct {
+	Modified time.Time `out:"header" header:"Last-Modified" json:"-" xml:"-" yaml:"-"`
+	Version  int       `json:"version" yaml:"version"`
+}
+
+type UserCreateRes struct {
+	Audit    `yaml:",inline"`
+	Status   int          `out:"status" json:"-" xml:"-" yaml:"-"`
+	Location string       `out:"header" header:"Location" json:"-" xml:"-" yaml:"-"`
+	ETag     string       `out:"header" header:"ETag" json:"-" xml:"-" yaml:"-"`
+	Vary     []string     `out:"header" json:"-" xml:"-" yaml:"-"`
+	Session  *http.Cookie `out:"cookie" json:"-" xml:"-" yaml:"-"`
+	Theme    string       `out:"cookie" cookie:"theme" json:"-" xml:"-" yaml:"-"`
+	Id       int          `json:"id" yaml:"id"`
+	Name     string       `json:"name" yaml:"name"`
+}
+
+type (
+	BadOutRes struct {
+		Status string `out:"status" json:"-" xml:"-" yaml:"-"`
+	}
+	// UntaggedOutRes 的 out 字段没有排除在 XML 与 YAML 之外
+	UntaggedOutRes struct {
+		Status int `out:"status" json:"-"`
+	}
+)
+
+func TestOut(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	r := gin.New()
+	r.POST("/users", handle.NewReqResFunc(func(ctx context.Context, req *UserCreateReq) (*UserCreateRes, error) {
+		return &UserCreateRes{
+			Audit:    Audit{Modified: modified, Version: 1},
+			Status:   http.StatusCreated,
+			Location: "/users/1",
+			ETag:     `"v1"`,
+			Vary:     []string{"Accept", "Cookie"},
+			Session:  &http.Cookie{Name: "sid", Value: "abc", Path: "/", HttpOnly: true},
+			Id:       1,
+			Name:     req.Name,
+		}, nil
+	}).DecodeFunc().Handler(handle.WithFormats(handle.FormatJSON, handle.FormatXML, handle.FormatYAML)))
+	r.POST("/bare", handle.NewReqResFunc(func(ctx context.Context, req *UserCreateReq) (*UserCreateRes, error) {
+		return &UserCreateRes{Audit: Audit{Version: 1}, Status: http.StatusCreated, Id: 1, Name: req.Name}, nil
+	}).Handler(handle.WithEnvelope(handle.BareEnvelope{}), handle.WithFormats(handle.FormatJSON, handle.FormatXML)))
+	r.POST("/default", handle.NewReqResFunc(func(ctx context.Context, req *UserCreateReq) (UserCreateRes, error) {
+		return UserCreateRes{Name: req.Name}, nil
+	}).DecodeFunc().Handler())
+
+	t.Run("created", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"gee"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("status = %d, want 201", w.Code)
+		}
+		if want := `{"code":200,"msg":"","data":{"version":1,"id":1,"name":"gee"}}`; w.Body.String() != want {
+			t.Errorf("body = %s, want %s", w.Body, want)
+		}
+		header := w.Header()
+		if got := header.Get("Location"); got != "/users/1" {
+			t.Errorf("Location = %q", got)
+		}
+		if got := header.Get("ETag"); got != `"v1"` {
+			t.Errorf("ETag = %q", got)
+		}
+		if got := header.Get("Last-Modified"); got != "Tue, 02 Jan 2024 03:04:05 GMT" {
+			t.Errorf("Last-Modified = %q", got)
+		}
+		if got := header.Values("Vary"); len(got) != 2 {
+			t.Errorf("Vary = %q", got)
+		}
+		if got := header.Values("Set-Cookie"); len(got) != 1 || got[0] != "sid=abc; Path=/; HttpOnly" {
+			t.Errorf("Set-Cookie = %q", got)
+		}
+	})
+
+	t.Run("zero values", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/default", strings.NewReader(`{"name":"gee"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+		if want := `{"code":200,"msg":"","data":{"version":0,"id":0,"name":"gee"}}`; w.Body.String() != want {
+			t.Errorf("body = %s, want %s", w.Body, want)
+		}
+		for _, key := range []string{"Location", "ETag", "Last-Modified", "Set-Cookie"} {
+			if got := w.Header().Get(key); got != "" {
+				t.Errorf("%s = %q, want empty", key, got)
+			}
+		}
+	})
+
+	t.Run("formats", func(t *testing.T) {
+		for _, tt := range []struct{ target, accept, want string }{
+			{"/users", "application/xml", `<Response><code>200</code><msg></msg><data><Version>1</Version><Id>1</Id><Name>gee</Name></data></Response>`},
+			{"/bare", "application/xml", `<UserCreateRes><Version>1</Version><Id>1</Id><Name>gee</Name></UserCreateRes>`},
+			{"/users", "application/yaml", "code: 200\nmsg: \"\"\ndata:\n    version: 1\n    id: 1\n    name: gee\n"},
+		} {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(`{"name":"gee"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", tt.accept)
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusCreated || w.Body.String() != tt.want {
+				t.Errorf("%s (%s): got %d %s, want 201 %s", tt.target, tt.accept, w.Code, w.Body, tt.want)
+			}
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var se *handle.SignatureError
+		_, err := handle.TryNewReqResFunc(func(ctx context.Context, req *UserCreateReq) (*BadOutRes, error) { return nil, nil })
+		if !errors.As(err, &se) || se.Rule != handle.RuleResult {
+			t.Fatalf("err = %v, want %s", err, handle.RuleResult)
+		}
+		// 响应体原样编码返回参数，out 字段必须排除在所有格式之外
+		_, err = handle.TryNewReqResFunc(func(ctx context.Context, req *UserCreateReq) (*UntaggedOutRes, error) { return nil, nil })
+		if !errors.As(err, &se) || se.Rule != handle.RuleResult || !strings.Contains(se.Msg, `json:"-" xml:"-" yaml:"-"`) {
+			t.Fatalf("err = %v, want %s", err, handle.RuleResult)
+		}
+	})
+}
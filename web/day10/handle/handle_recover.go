@@ -0,0 +1,80 @@
+package handle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PanicError 处理函数、拦截器或请求参数解析时触发的 panic，由 ReqResFunc 与 TypedFunc 的 Call 或 handler 恢复后返回，
+// 统一转换为 CodeInternal，调用栈只在调试模式下返回给客户端，见 WithDebug
+type PanicError struct {
+	Name  string // 处理函数的名称，见 Invocation.Name
+	Value any    // recover() 的返回值
+	Stack []byte // 触发 panic 时的调用栈
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("panic in %s: %v", e.Name, e.Value) }
+
+// PanicDetails 调试模式下 panic 的错误详情，对应 Response.Data
+type PanicDetails struct {
+	Panic string   `json:"panic" xml:"panic" yaml:"panic"`
+	Stack []string `json:"stack" xml:"stack" yaml:"stack"`
+}
+
+// WithDebug 设置是否为调试模式，调试模式下 panic 的错误详情包含调用栈，默认关闭；
+// 调用栈不应返回给生产环境的客户端，只在本地开发时开启，日志中始终记录调用栈
+func WithDebug(debug bool) Option {
+	return func(o *options) { o.debug = debug }
+}
+
+// recoverPanic 将 panic 转换为 *PanicError 写入 err，需要直接 defer 调用；
+// http.ErrAbortHandler 用于主动中断响应，会继续 panic
+func recoverPanic(name string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if r == http.ErrAbortHandler {
+		panic(r)
+	}
+	*err = &PanicError{Name: name, Value: r, Stack: debug.Stack()}
+}
+
+// call 调用 f 并恢复 panic，使 handle.Handle 直接传入的 DecodeFunc 同样返回 *PanicError，name 为 f 的函数名；
+// ReqResFunc 与 TypedFunc 的 Call 已经自行恢复，PanicError.Name 仍为它们的方法名
+func (f DecodeFunc) call(ctx context.Context, name string, decode func(point any) error) (data any, err error) {
+	defer recoverPanic(name, &err)
+	return f(ctx, decode)
+}
+
+// failure 以 Envelope.Failure 包装错误：5xx 的原始错误交由 gin 的日志中间件输出，
+// panic 额外记录路由、请求 id 与调用栈
+func (o *options) failure(c *gin.Context, err error) (int, any) {
+	e := ToError(err)
+	if e.Status >= http.StatusInternalServerError {
+		_ = c.Error(err) // 记录原始错误，交由 gin 的日志中间件输出
+	}
+
+	var p *PanicError
+	if errors.As(err, &p) {
+		slog.ErrorContext(c, "handle: panic recovered",
+			"handler", p.Name,
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"request_id", requestID(c),
+			"panic", fmt.Sprint(p.Value),
+			"stack", string(p.Stack),
+		)
+		if o.debug {
+			e = e.WithDetails(PanicDetails{Panic: fmt.Sprint(p.Value), Stack: strings.Split(strings.TrimSpace(string(p.Stack)), "\n")})
+		}
+	}
+	return o.envelope.Failure(c, localize(c, e))
+}
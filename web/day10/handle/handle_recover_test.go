@@ -0,0 +1,125 @@
+package handle_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PanicReq struct {
+	Id int `uri:"id"`
+}
+
+func panicky(ctx context.Context, req *PanicReq) (*EchoRes, error) {
+	var m map[int]string
+	m[req.Id] = "boom" // assignment to entry in nil map
+	return nil, nil
+}
+
+func TestRecover(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(handle.RequestID())
+	r.GET("/panic/:id", handle.NewReqResFunc(panicky).DecodeFunc().Handler())
+	r.GET("/debug/:id", handle.NewReqResFunc(panicky).DecodeFunc().Handler(handle.WithDebug(true)))
+	r.GET("/typed/:id", handle.Typed(panicky).Handler())
+
+	for _, target := range []string{"/panic/1", "/typed/1"} {
+		logs.Reset()
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		r.ServeHTTP(w, req)
+
+		if want := `{"code":500,"msg":"Internal Server Error","data":null,"requestId":"req-1"}`; w.Code != http.StatusInternalServerError || w.Body.String() != want {
+			t.Errorf("%s: got %d %s, want 500 %s", target, w.Code, w.Body, want)
+		}
+		for _, want := range []string{"panic recovered", "route=/", "request_id=req-1", "nil map", "handle_recover_test.go"} {
+			if !strings.Contains(logs.String(), want) {
+				t.Errorf("%s: log %q does not contain %q", target, logs.String(), want)
+			}
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/1", nil))
+	var res struct {
+		Code int                 `json:"code"`
+		Data handle.PanicDetails `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Code != handle.CodeInternal || !strings.Contains(res.Data.Panic, "nil map") || len(res.Data.Stack) == 0 {
+		t.Errorf("got debug response %s", w.Body)
+	}
+}
+
+func TestRecoverGinDebugMode(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// gin 的调试模式不会让调用栈返回给客户端，需要显式 WithDebug(true)
+	gin.SetMode(gin.DebugMode)
+	defer gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/panic/:id", handle.NewReqResFunc(panicky).DecodeFunc().Handler())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic/1", nil))
+	if want := `{"code":500,"msg":"Internal Server Error","data":null}`; w.Body.String() != want {
+		t.Errorf("got %s, want %s", w.Body, want)
+	}
+}
+
+func TestRecoverCall(t *testing.T) {
+	f := handle.NewReqResFunc(panicky)
+	_, err := f.Call(context.Background(), func(point any) error { return nil })
+
+	var p *handle.PanicError
+	if !errors.As(err, &p) || !strings.HasSuffix(p.Name, "handle_test.panicky") || len(p.Stack) == 0 {
+		t.Fatalf("got %v, want *handle.PanicError", err)
+	}
+	if e := handle.ToError(err); e.Code != handle.CodeInternal {
+		t.Errorf("got code %d, want %d", e.Code, handle.CodeInternal)
+	}
+}
+
+func TestRecoverHandle(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	// 直接传入 handle.Handle 的 DecodeFunc 没有经过 ReqResFunc 的 Call，同样需要恢复 panic
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/panic", handle.Handle(func(ctx context.Context, decode func(point any) error) (any, error) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if want := `{"code":500,"msg":"Internal Server Error","data":null}`; w.Code != http.StatusInternalServerError || w.Body.String() != want {
+		t.Errorf("got %d %s, want 500 %s", w.Code, w.Body, want)
+	}
+	for _, want := range []string{"panic recovered", "handler=gee/web/day10/handle_test.TestRecoverHandle.func1", "boom"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("log %q does not contain %q", logs.String(), want)
+		}
+	}
+}
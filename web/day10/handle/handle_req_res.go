@@ -2,85 +2,218 @@ package handle
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
-	"strings"
+	"runtime"
+
+	"github.com/gin-gonic/gin"
 )
 
 type ReqResFunc struct {
 	fn reflect.Value // 函数调用入口
 
 	ctx reflect.Type // 第一个请求参数：context.Context
-	req reflect.Type // 第二个请求参数：XXXReq
+	req reflect.Type // 第二个请求参数：*XXXReq 或 XXXReq，没有请求参数时为 nil
+
+	res reflect.Type // 第一个返回参数：*XXXRes、XXXRes、切片、map、channel、EventStream 或 Responder，没有返回数据时为 nil
+	err reflect.Type // 最后一个返回参数：error
 
-	res reflect.Type // 第一个返回参数：XXXRes
-	err reflect.Type // 第二个返回参数：error
+	name         string            // 方法名，见 Invocation.Name
+	meta         reflect.StructTag // 请求参数 meta 字段的标签
+	interceptors []Interceptor     // 全局、结构体与方法的拦截器
+	opts         []Option          // meta 标签声明的选项，见 metaOptions
+}
+
+// NewReqResFunc 返回 ReqResFunc，参数 reqRes 支持以下格式，否则会触发 panic，
+// 需要返回错误时使用 TryNewReqResFunc：
+//
+//	func(context.Context, *XXXReq) (*XXXRes, error)
+//	func(context.Context, XXXReq) (XXXRes, error)    // 请求参数与返回参数都可以是结构体或结构体指针
+//	func(context.Context) (*XXXRes, error)           // 没有请求参数，不会解析请求
+//	func(context.Context, *XXXReq) error             // 没有返回数据，响应的 data 为 null
+//	func(context.Context, *XXXReq) ([]*XXXRes, error) // 返回切片或 map，元素类型不限
+//	func(context.Context, *XXXReq) (<-chan *XXXRes, error) // 以 Server-Sent Events 返回，见 EventStream
+//	func(context.Context, *XXXReq) (*handle.File, error)    // 自行写入响应，见 Responder
+//
+// 结构体的命名规范默认为 XxxReq、XxxRes，可以通过 WithNaming 修改；通过 WithInterceptors 添加拦截器
+func NewReqResFunc(reqRes any, opts ...Option) *ReqResFunc {
+	f, err := TryNewReqResFunc(reqRes, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return f
 }
 
-// NewReqResFunc 返回 ReqResFunc
-// 参数 reqResFunc 必须是 func(context.Context, *XXXReq) (*XXXRes, error) 格式，否则会触发 panic
-func NewReqResFunc(reqRes any) *ReqResFunc {
+// TryNewReqResFunc 与 NewReqResFunc 相同，签名不符合要求时返回所有的 *SignatureError，可以通过 errors.As 读取
+func TryNewReqResFunc(reqRes any, opts ...Option) (*ReqResFunc, error) {
 	fn := reflect.ValueOf(reqRes)
+	f, errs := newReqResFunc(fn, funcName(fn), newOptions(opts))
+	return f, errors.Join(errs...)
+}
+
+// newReqResFunc 校验函数签名与命名规范，method 为错误中的方法名，o 提供命名规范与拦截器
+func newReqResFunc(fn reflect.Value, method string, o *options) (*ReqResFunc, []error) {
+	invalid := func(rule Rule, t reflect.Type, format string, args ...any) error {
+		return &SignatureError{Method: method, Type: t, Rule: rule, Msg: fmt.Sprintf(format, args...)}
+	}
+
+	if !fn.IsValid() {
+		return nil, []error{invalid(RuleFunc, nil, "handler must be a function")}
+	}
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		return nil, []error{invalid(RuleFunc, fn.Type(), "handler must be a non-nil function")}
+	}
 	fnType := fn.Type()
 
-	if fnType.NumIn() != 2 {
-		panic("parameter must be context.Context and XXXReq")
+	var errs []error
+	if fnType.NumIn() != 1 && fnType.NumIn() != 2 {
+		errs = append(errs, invalid(RuleParams, fnType, "parameter must be context.Context and optional XXXReq"))
 	}
-	if fnType.NumOut() != 2 {
-		panic("return value must be XXXRes and error")
+	if fnType.NumOut() != 1 && fnType.NumOut() != 2 {
+		errs = append(errs, invalid(RuleResults, fnType, "return value must be optional XXXRes and error"))
+	}
+	if len(errs) > 0 {
+		return nil, errs
 	}
 
-	ctx, req := fnType.In(0), fnType.In(1)
-	res, err := fnType.Out(0), fnType.Out(1)
+	f := &ReqResFunc{
+		fn:   fn,
+		ctx:  fnType.In(0),
+		err:  fnType.Out(fnType.NumOut() - 1),
+		name: method,
+	}
+	if fnType.NumIn() == 2 {
+		f.req = fnType.In(1)
+	}
+	if fnType.NumOut() == 2 {
+		f.res = fnType.Out(0)
+	}
 
-	if !ctx.Implements(reflect.TypeOf((*context.Context)(nil)).Elem()) {
-		panic("the first parameter must be context.Context")
+	if !f.ctx.Implements(reflect.TypeOf((*context.Context)(nil)).Elem()) {
+		errs = append(errs, invalid(RuleContext, f.ctx, "the first parameter must be context.Context"))
 	}
-	if !err.Implements(reflect.TypeOf((*error)(nil)).Elem()) {
-		panic("the second return value must be error")
+	if !f.err.Implements(reflect.TypeOf((*error)(nil)).Elem()) {
+		errs = append(errs, invalid(RuleError, f.err, "the last return value must be error"))
 	}
 
-	// req.Kind() must be *Struct
-	// todo add Struct
-	if req.Kind() == reflect.Pointer && req.Elem().Kind() == reflect.Struct {
-		if !strings.HasSuffix(req.String(), "Req") {
-			panic(fmt.Sprintf(`invalid struct name for request: defined as "%s", but it should be named with "Res" suffix like "XxxReq" or "*XxxReq"`, req.String()))
+	// req.Kind() must be Struct or *Struct
+	if req := f.req; req != nil {
+		if req.Kind() == reflect.Struct ||
+			(req.Kind() == reflect.Pointer && req.Elem().Kind() == reflect.Struct) {
+			if err := o.naming.CheckRequest(req); err != nil {
+				errs = append(errs, invalid(RuleRequestName, req, "%v", err))
+			}
+			meta, ics, err := metaInterceptors(req)
+			if err != nil {
+				errs = append(errs, invalid(RuleInterceptor, req, "%v", err))
+			}
+			f.meta = meta
+			f.interceptors = append(append([]Interceptor(nil), o.interceptors...), ics...)
+			if f.opts, err = metaOptions(meta); err != nil {
+				errs = append(errs, invalid(RuleMeta, req, "%v", err))
+			}
+			if _, err := sourceFields(structOf(req)); err != nil {
+				errs = append(errs, invalid(RuleIn, req, "%v", err))
+			}
+			if err := checkRules(req); err != nil {
+				errs = append(errs, invalid(RuleValidate, req, "%v", err))
+			}
+			if _, err := defaultFields(structOf(req)); err != nil {
+				errs = append(errs, invalid(RuleDefault, req, "%v", err))
+			}
+		} else {
+			errs = append(errs, invalid(RuleRequest, req, `the second parameter should be like "BizReq" or "*BizReq"`))
 		}
-	} else {
-		panic(fmt.Sprintf(`invalid handler: defined as "%s", but type of the  second input parameter should be like "BizReq" or "*BizReq"`, req.String()))
 	}
 
-	// res.Kind() must be Struct or *Struct
-	if res.Kind() == reflect.Struct ||
-		(res.Kind() == reflect.Pointer && res.Elem().Kind() == reflect.Struct) {
-		if !strings.HasSuffix(res.String(), "Res") {
-			panic(fmt.Sprintf(`invalid struct name for request: defined as "%s", but it should be named with "Res" suffix like "XxxRes" or "*XxxReq"`, res.String()))
+	// res.Kind() must be Struct, *Struct, Slice or Map
+	if res := f.res; res != nil {
+		switch {
+		case isResponder(res): // 自行写入响应，见 Responder
+		case res.Kind() == reflect.Slice || res.Kind() == reflect.Map:
+		case isEventSource(res): // Server-Sent Events，见 EventStream
+		case res.Kind() == reflect.Struct ||
+			(res.Kind() == reflect.Pointer && res.Elem().Kind() == reflect.Struct):
+			if err := o.naming.CheckResult(res); err != nil {
+				errs = append(errs, invalid(RuleResultName, res, "%v", err))
+			}
+			if _, err := outFields(structOf(res)); err != nil {
+				errs = append(errs, invalid(RuleResult, res, "%v", err))
+			}
+		default:
+			errs = append(errs, invalid(RuleResult, res, `the first return value should be "BizRes", "*BizRes", a slice, a map, a channel, handle.EventStream or handle.Responder`))
 		}
-	} else {
-		panic(fmt.Sprintf(`invalid handler: defined as "%s", but type of the first output parameter should be "BizRes" or "*BizRes"`, res.String()))
 	}
 
-	return &ReqResFunc{
-		fn:  fn,
-		ctx: ctx,
-		req: req,
-		res: res,
-		err: err,
+	if len(errs) > 0 {
+		return nil, errs
 	}
+	if f.req == nil {
+		f.interceptors = o.interceptors
+	}
+	return f, nil
 }
 
-func (f *ReqResFunc) Call(ctx context.Context, decode func(point any) error) (any, error) {
-	req := reflect.New(f.req.Elem())
-	point := req.Interface()
+// funcName 返回函数的完整名称，如 gee/web/day10/internal/controller.(*team).GetUsers-fm
+func funcName(fn reflect.Value) string {
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		return ""
+	}
+	if f := runtime.FuncForPC(fn.Pointer()); f != nil {
+		return f.Name()
+	}
+	return ""
+}
+
+// Call 解析请求参数，经过拦截器后调用处理函数，panic 会转换为 *PanicError 返回
+func (f *ReqResFunc) Call(ctx context.Context, decode func(point any) error) (res any, err error) {
+	defer recoverPanic(f.name, &err)
+
+	inv := &Invocation{Name: f.name, Meta: f.meta}
+	if f.req != nil {
+		req := reflect.New(f.Req())
+		if err := decode(req.Interface()); err != nil {
+			return nil, err
+		}
+		inv.Req = req.Interface()
+	}
+	if len(f.interceptors) == 0 {
+		return f.invoke(ctx, inv)
+	}
+	return intercept(ctx, inv, f.interceptors, func(ctx context.Context) (any, error) { return f.invoke(ctx, inv) })
+}
 
-	if err := decode(point); err != nil {
-		return nil, err
+// invoke 以 inv.Req 调用处理函数
+func (f *ReqResFunc) invoke(ctx context.Context, inv *Invocation) (any, error) {
+	ctxValue := reflect.ValueOf(ctx)
+	if !ctxValue.Type().AssignableTo(f.ctx) {
+		// 第一个参数声明为 *gin.Context 时，从 ctx 中取出
+		c := GinContext(ctx)
+		if c == nil || !reflect.TypeOf(c).AssignableTo(f.ctx) {
+			return nil, fmt.Errorf("%s: context %T is not assignable to %s", f.name, ctx, f.ctx)
+		}
+		ctxValue = reflect.ValueOf(c)
+	}
+	args := []reflect.Value{ctxValue}
+	if f.req != nil {
+		if t := reflect.PointerTo(f.Req()); reflect.TypeOf(inv.Req) != t {
+			return nil, fmt.Errorf("%s: request %T passed by interceptor is not %s", f.name, inv.Req, t)
+		}
+		req := reflect.ValueOf(inv.Req)
+		if f.req.Kind() != reflect.Pointer {
+			req = req.Elem()
+		}
+		args = append(args, req)
 	}
 
-	result := f.fn.Call([]reflect.Value{reflect.ValueOf(ctx), req})
-	if err := result[1]; !err.IsNil() {
+	result := f.fn.Call(args)
+	if err := result[len(result)-1]; !err.IsNil() {
 		return nil, err.Interface().(error)
 	}
+	if f.res == nil {
+		return nil, nil
+	}
 	return result[0].Interface(), nil
 }
 
@@ -88,10 +221,19 @@ func (f *ReqResFunc) DecodeFunc() DecodeFunc {
 	return f.Call
 }
 
+// Handler 返回 gin.HandlerFunc，与 DecodeFunc().Handler 相同，但会排除无法编码返回参数的格式，
+// 并应用 meta 标签声明的选项
+func (f *ReqResFunc) Handler(opts ...Option) gin.HandlerFunc { return funcHandler(f, opts) }
+
+func (f *ReqResFunc) metaOptions() []Option { return f.opts }
+
+// Req 返回请求参数的结构体类型，没有请求参数时为 nil
 func (f *ReqResFunc) Req() reflect.Type {
-	if f.req.Kind() == reflect.Pointer {
+	if f.req != nil && f.req.Kind() == reflect.Pointer {
 		return f.req.Elem()
 	}
 	return f.req
 }
+
+// Res 返回返回参数的类型，没有返回数据时为 nil
 func (f *ReqResFunc) Res() reflect.Type { return f.res }
@@ -0,0 +1,175 @@
+package handle_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	PingRes struct {
+		Pong bool `json:"pong"`
+	}
+	TagReq struct {
+		Id int `uri:"id"`
+	}
+)
+
+func TestNewReqResFunc(t *testing.T) {
+	tests := []struct {
+		name     string
+		fn       any
+		req, res reflect.Type
+		want     any
+	}{
+		{
+			name: "pointer",
+			fn:   Echo,
+			req:  reflect.TypeOf(EchoReq{}), res: reflect.TypeOf(&EchoRes{}),
+			want: map[string]any{"id": 7.0, "name": "gee"},
+		},
+		{
+			name: "value",
+			fn: func(ctx context.Context, req EchoReq) (EchoRes, error) {
+				return EchoRes{Id: req.Id, Name: req.Name}, nil
+			},
+			req: reflect.TypeOf(EchoReq{}), res: reflect.TypeOf(EchoRes{}),
+			want: map[string]any{"id": 7.0, "name": "gee"},
+		},
+		{
+			name: "without request",
+			fn: func(ctx context.Context) (*PingRes, error) {
+				return &PingRes{Pong: true}, nil
+			},
+			res:  reflect.TypeOf(&PingRes{}),
+			want: map[string]any{"pong": true},
+		},
+		{
+			name: "without result",
+			fn: func(ctx context.Context, req *EchoReq) error {
+				if req.Id != 7 {
+					t.Errorf("req.Id = %d, want 7", req.Id)
+				}
+				return nil
+			},
+			req: reflect.TypeOf(EchoReq{}),
+		},
+		{
+			name: "slice",
+			fn: func(ctx context.Context, req *EchoReq) ([]*EchoRes, error) {
+				return []*EchoRes{{Id: req.Id, Name: req.Name}}, nil
+			},
+			req: reflect.TypeOf(EchoReq{}), res: reflect.TypeOf([]*EchoRes{}),
+			want: []any{map[string]any{"id": 7.0, "name": "gee"}},
+		},
+		{
+			name: "map",
+			fn: func(ctx context.Context, req *EchoReq) (map[string]int, error) {
+				return map[string]int{req.Name: req.Id}, nil
+			},
+			req: reflect.TypeOf(EchoReq{}), res: reflect.TypeOf(map[string]int{}),
+			want: map[string]any{"gee": 7.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := handle.NewReqResFunc(tt.fn)
+			if f.Req() != tt.req || f.Res() != tt.res {
+				t.Errorf("got Req() = %v, Res() = %v, want %v, %v", f.Req(), f.Res(), tt.req, tt.res)
+			}
+
+			status, resp := serve(t, http.MethodGet, "/echo/:id", "/echo/7?name=gee", f.DecodeFunc().Handler())
+			if status != http.StatusOK || resp.Code != handle.CodeOK || !reflect.DeepEqual(resp.Data, tt.want) {
+				t.Errorf("got status = %d, resp = %+v, want data %v", status, resp, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewReqResFuncInvalid(t *testing.T) {
+	tests := map[string]any{
+		"no context":     func(req *EchoReq) (*EchoRes, error) { return nil, nil },
+		"no error":       func(ctx context.Context, req *EchoReq) *EchoRes { return nil },
+		"too many":       func(ctx context.Context, req *EchoReq, id int) (*EchoRes, error) { return nil, nil },
+		"result name":    func(ctx context.Context, req *TagReq) (*EchoReq, error) { return nil, nil },
+		"request kind":   func(ctx context.Context, id int) (*EchoRes, error) { return nil, nil },
+		"result kind":    func(ctx context.Context, req *EchoReq) (int, error) { return 0, nil },
+		"error not last": func(ctx context.Context, req *EchoReq) (error, *EchoRes) { return nil, nil },
+	}
+	for name, fn := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewReqResFunc(%T) did not panic", fn)
+				}
+			}()
+			handle.NewReqResFunc(fn)
+		})
+	}
+}
+
+func TestTryNewReqResFunc(t *testing.T) {
+	_, err := handle.TryNewReqResFunc(func(ctx context.Context, req *PingRes) (*EchoReq, error) { return nil, nil })
+
+	var rules []handle.Rule
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var se *handle.SignatureError
+		if !errors.As(e, &se) {
+			t.Fatalf("%v is not a *SignatureError", e)
+		}
+		rules = append(rules, se.Rule)
+	}
+	want := []handle.Rule{handle.RuleRequestName, handle.RuleResultName}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("rules = %v, want %v", rules, want)
+	}
+
+	if _, err := handle.TryNewReqResFunc(Echo); err != nil {
+		t.Errorf("TryNewReqResFunc(Echo) = %v", err)
+	}
+}
+
+type Broken struct{}
+
+func (Broken) Echo(ctx context.Context, req *EchoReq) (*EchoRes, error) { return nil, nil }
+func (Broken) NoContext(req *EchoReq) (*EchoRes, error)                 { return nil, nil }
+func (Broken) NoError(ctx context.Context, req *EchoReq) *EchoRes       { return nil }
+
+func TestTryObjectHandler(t *testing.T) {
+	var methods []string
+	err := handle.TryObjectHandler(Broken{}, func(fn *handle.ReqResFunc, methodName string) {
+		methods = append(methods, methodName)
+	})
+
+	// 签名正确的方法仍然会被处理
+	if !reflect.DeepEqual(methods, []string{"Echo"}) {
+		t.Errorf("methods = %v, want [Echo]", methods)
+	}
+
+	var got []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		se := e.(*handle.SignatureError)
+		got = append(got, se.Method+":"+string(se.Rule))
+	}
+	want := []string{"Broken.NoContext:context", "Broken.NoError:error"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("errors = %v, want %v", got, want)
+	}
+
+	// RegisterObject 汇总签名错误，而不是 panic
+	if err := handle.RegisterObject(gin.New(), Broken{}); err == nil || !strings.Contains(err.Error(), "Broken.NoError") {
+		t.Errorf("RegisterObject = %v", err)
+	}
+
+	if err := handle.TryObjectHandler(1, nil); err == nil {
+		t.Error("TryObjectHandler(1) = nil")
+	}
+}
@@ -0,0 +1,50 @@
+package handle
+
+import (
+	"gee/web/day10/trace"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID 返回读取或生成请求 id 的中间件，需要在路由之前注册：
+//
+//	r.Use(handle.RequestID())
+//
+// 请求头 X-Request-ID 合法时沿用，否则生成新的请求 id；请求 id 会写入响应头 X-Request-ID、
+// 响应体的 requestId（见 Response）、请求的 context 与 *gin.Context，服务层通过 trace.RequestID 与 trace.Logger 读取
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(trace.Header)
+		if !validRequestID(id) {
+			id = trace.NewRequestID()
+		}
+		c.Header(trace.Header, id)
+		c.Request = c.Request.WithContext(trace.WithRequestID(c.Request.Context(), id))
+		// 处理函数的 ctx 基于请求的 context，不需要 trace.Key；但声明为 *gin.Context 的处理函数、
+		// gin 的中间件会把 *gin.Context 作为 ctx 传给服务层，而 gin 默认不回退到请求的 context（ContextWithFallback），
+		// *gin.Context 的 Value 只能读取 c.Set 写入的字符串 key
+		c.Set(trace.Key, id)
+		c.Next()
+	}
+}
+
+// validRequestID 客户端传递的请求 id 最长 128 个字符，只能包含可见的 ASCII 字符，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestID 返回请求 id，没有注册 RequestID 中间件时为空
+func requestID(c *gin.Context) string {
+	if c == nil || c.Request == nil {
+		return ""
+	}
+	return trace.RequestID(c.Request.Context())
+}
@@ -0,0 +1,63 @@
+package handle_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+	"gee/web/day10/trace"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TraceReq struct{}
+
+type TraceRes struct {
+	RequestId string `json:"id"`
+}
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(handle.RequestID())
+	// 服务层只依赖 context.Context 读取请求 id
+	r.GET("/trace", handle.NewReqResFunc(func(ctx context.Context, req *TraceReq) (*TraceRes, error) {
+		return &TraceRes{RequestId: trace.RequestID(ctx)}, nil
+	}).DecodeFunc().Handler())
+	// 第一个参数声明为 *gin.Context 时，直接把 c 作为 ctx 传给服务层也能读取，见 trace.Key
+	r.GET("/gin", handle.NewReqResFunc(func(c *gin.Context, req *TraceReq) (*TraceRes, error) {
+		return &TraceRes{RequestId: trace.RequestID(c)}, nil
+	}).DecodeFunc().Handler())
+
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+	tests := []struct {
+		target, header string
+		want           func(id string) bool
+	}{
+		{"/trace", "", generated.MatchString},
+		{"/trace", "req-1", func(id string) bool { return id == "req-1" }},
+		{"/trace", "bad id\r\n", generated.MatchString}, // 非法的请求 id 会被替换
+		{"/gin", "req-2", func(id string) bool { return id == "req-2" }},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.header != "" {
+			req.Header.Set(trace.Header, tt.header)
+		}
+		r.ServeHTTP(w, req)
+
+		id := w.Header().Get(trace.Header)
+		if w.Code != http.StatusOK || !tt.want(id) {
+			t.Errorf("%s %q: got %d, X-Request-ID = %q", tt.target, tt.header, w.Code, id)
+			continue
+		}
+		if want := `"data":{"id":"` + id + `"},"requestId":"` + id + `"`; !strings.Contains(w.Body.String(), want) {
+			t.Errorf("%s %q: body = %s, want %s", tt.target, tt.header, w.Body, want)
+		}
+	}
+}
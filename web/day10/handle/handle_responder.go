@@ -0,0 +1,151 @@
+package handle
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+)
+
+// Responder 自行写入状态码、响应头与响应体的返回值，不经过 Envelope 与内容协商：
+//
+//	func (u *user) Export(ctx context.Context, req *UserExportReq) (*handle.Stream, error)
+//	func (u *user) Avatar(ctx context.Context, req *UserAvatarReq) (*handle.File, error)
+//	func (u *user) Login(ctx context.Context, req *UserLoginReq) (*handle.Redirect, error)
+//
+// Respond 返回错误且尚未写入响应时，错误与其他处理函数一样经过 Envelope 返回；
+// 已经写入响应时只记录错误
+type Responder interface {
+	Respond(w http.ResponseWriter, r *http.Request) error
+}
+
+var responderType = reflect.TypeOf((*Responder)(nil)).Elem()
+
+// isResponder 类型 t 或其指针是否实现了 Responder
+func isResponder(t reflect.Type) bool {
+	return t.Implements(responderType) || (t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface && reflect.PointerTo(t).Implements(responderType))
+}
+
+// asResponder data 是否为非 nil 的 Responder，指针实现了 Responder 的值会被转换为指针
+func asResponder(data any) (Responder, bool) {
+	if v := reflect.ValueOf(data); v.IsValid() && v.Kind() != reflect.Pointer && reflect.PointerTo(v.Type()).Implements(responderType) {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		data = ptr.Interface()
+	}
+	r, ok := data.(Responder)
+	if !ok {
+		return nil, false
+	}
+	if v := reflect.ValueOf(r); v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil, false
+	}
+	return r, true
+}
+
+// File 返回文件，支持 Range、If-Modified-Since 等条件请求
+type File struct {
+	Path        string        // 文件路径，Content 为 nil 时读取
+	Content     io.ReadSeeker // 文件内容
+	Name        string        // 文件名，非空时以附件形式下载
+	ContentType string        // 为空时根据文件名的扩展名或内容判断
+	ModTime     time.Time     // 最后修改时间，为零值且读取 Path 时使用文件的修改时间
+}
+
+var errFileNotFound = NewError(CodeNotFound, http.StatusNotFound, "")
+
+func (f *File) Respond(w http.ResponseWriter, r *http.Request) error {
+	content, modTime := f.Content, f.ModTime
+	if content == nil {
+		file, err := os.Open(f.Path)
+		if errors.Is(err, os.ErrNotExist) {
+			return errFileNotFound.Wrap(err)
+		}
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return errFileNotFound.Wrap(errors.New(f.Path + " is a directory"))
+		}
+		if modTime.IsZero() {
+			modTime = info.ModTime()
+		}
+		content = file
+	}
+
+	name := f.Name
+	if name == "" {
+		name = filepath.Base(f.Path)
+	}
+	if f.ContentType != "" {
+		w.Header().Set("Content-Type", f.ContentType)
+	}
+	if f.Name != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": f.Name}))
+	}
+	http.ServeContent(w, r, name, modTime, content)
+	return nil
+}
+
+// Stream 以流的形式返回任意格式的数据，如导出 CSV，Body 与 Write 二选一
+type Stream struct {
+	Status      int                     // HTTP 状态码，默认为 200
+	ContentType string                  // 默认为 application/octet-stream
+	Name        string                  // 文件名，非空时以附件形式下载
+	Body        io.Reader               // 响应体，读取完毕后如果实现了 io.Closer 会被关闭
+	Write       func(w io.Writer) error // 写入响应体，返回的错误只会被记录
+}
+
+func (s *Stream) Respond(w http.ResponseWriter, r *http.Request) error {
+	if closer, ok := s.Body.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	contentType := s.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	if s.Name != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": s.Name}))
+	}
+	status := s.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+
+	if s.Write != nil {
+		return s.Write(w)
+	}
+	if s.Body != nil {
+		_, err := io.Copy(w, s.Body)
+		return err
+	}
+	return nil
+}
+
+// Redirect 重定向到 URL
+type Redirect struct {
+	URL    string // 目标地址，可以是相对路径
+	Status int    // 3xx 状态码，默认为 302
+}
+
+func (rd *Redirect) Respond(w http.ResponseWriter, r *http.Request) error {
+	status := rd.Status
+	if status == 0 {
+		status = http.StatusFound
+	}
+	http.Redirect(w, r, rd.URL, status)
+	return nil
+}
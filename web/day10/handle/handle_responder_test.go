@@ -0,0 +1,99 @@
+package handle_test
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportReq struct {
+	Name string `form:"name"`
+}
+
+func TestResponder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/export", handle.NewReqResFunc(func(ctx context.Context, req *ExportReq) (*handle.Stream, error) {
+		return &handle.Stream{ContentType: "text/csv", Name: "users.csv", Write: func(w io.Writer) error {
+			cw := csv.NewWriter(w)
+			_ = cw.Write([]string{"id", "name"})
+			_ = cw.Write([]string{"1", req.Name})
+			cw.Flush()
+			return cw.Error()
+		}}, nil
+	}).Handler())
+	r.GET("/file", handle.NewReqResFunc(func(ctx context.Context, req *ExportReq) (handle.File, error) {
+		return handle.File{Content: strings.NewReader("hello world"), Name: "hello.txt"}, nil
+	}).DecodeFunc().Handler())
+	r.GET("/missing", handle.NewReqResFunc(func(ctx context.Context, req *ExportReq) (*handle.File, error) {
+		return &handle.File{Path: filepath.Join(t.TempDir(), "missing.txt")}, nil
+	}).DecodeFunc().Handler())
+	r.GET("/redirect", handle.NewReqResFunc(func(ctx context.Context, req *ExportReq) (*handle.Redirect, error) {
+		return &handle.Redirect{URL: "/export?name=" + req.Name}, nil
+	}).DecodeFunc().Handler())
+
+	tests := []struct {
+		name, target string
+		header       []string
+		status       int
+		wantHeader   map[string]string
+		body         string
+	}{
+		{
+			// Accept 无法协商时仍然由 Responder 返回
+			name: "stream", target: "/export?name=gee", header: []string{"Accept", "text/csv"},
+			status:     200,
+			wantHeader: map[string]string{"Content-Type": "text/csv", "Content-Disposition": `attachment; filename=users.csv`},
+			body:       "id,name\n1,gee\n",
+		},
+		{
+			name: "file range", target: "/file", header: []string{"Range", "bytes=0-4"},
+			status:     206,
+			wantHeader: map[string]string{"Content-Type": "text/plain; charset=utf-8", "Content-Disposition": `attachment; filename=hello.txt`},
+			body:       "hello",
+		},
+		{
+			name: "file not found", target: "/missing",
+			status:     404,
+			wantHeader: map[string]string{"Content-Type": "application/json; charset=utf-8"},
+			body:       `{"code":404,"msg":"Not Found","data":null}`,
+		},
+		{
+			name: "redirect", target: "/redirect?name=gee",
+			status:     302,
+			wantHeader: map[string]string{"Location": "/export?name=gee"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for i := 0; i+1 < len(tt.header); i += 2 {
+				req.Header.Set(tt.header[i], tt.header[i+1])
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.status, w.Body)
+			}
+			for k, v := range tt.wantHeader {
+				if got := w.Header().Get(k); got != v {
+					t.Errorf("%s = %q, want %q", k, got, v)
+				}
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", w.Body, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package handle
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route 路由元数据
+type Route struct {
+	Method      string   // 请求方法，如 GET
+	Path        string   // 请求路径，相对于注册时的 router
+	FullPath    string   // 完整的请求路径，注册后由 RegisterRoutes 填充
+	Summary     string   // 路由摘要
+	Tags        []string // 路由分组
+	Middlewares []string // 中间件名称，需要先通过 RegisterMiddleware 注册
+	Name        string   // 方法名，如 Hello.GetHelloWorld
+	Func        Func     // 函数入口
+	Options     []Option // 构造 handler 的选项
+}
+
+func (r Route) String() string {
+	p := r.FullPath
+	if p == "" {
+		p = r.Path
+	}
+	return fmt.Sprintf("%s %s", r.Method, p)
+}
+
+var (
+	routesMu sync.RWMutex
+	routes   []Route // 已注册的路由表
+
+	middlewaresMu sync.RWMutex
+	middlewares   = map[string][]gin.HandlerFunc{}
+)
+
+// Routes 返回通过 handle 注册的路由表
+func Routes() []Route {
+	routesMu.RLock()
+	defer routesMu.RUnlock()
+
+	return append([]Route(nil), routes...)
+}
+
+// RegisterMiddleware 注册具名中间件，供 meta 标签的 middleware 引用
+func RegisterMiddleware(name string, handlers ...gin.HandlerFunc) {
+	middlewaresMu.Lock()
+	defer middlewaresMu.Unlock()
+
+	middlewares[name] = handlers
+}
+
+// RegisterRoutes 将 routes 注册到 router 并记录到路由表
+//
+// 所有路由都会先完成校验，只要存在一个错误就不会注册任何路由，
+// 返回的错误会列出每一个中间件未注册或路由冲突的方法；
+// 路由冲突的校验见 prepareRoutes，router 为 *gin.RouterGroup 时无法预先检测与分组外路由的冲突
+func RegisterRoutes(router gin.IRouter, rs ...Route) error {
+	handlers, err := prepareRoutes(router, rs)
+	if err != nil {
+		return err
+	}
+	return applyRoutes(router, rs, handlers)
+}
+
+// prepareRoutes 校验路由，并返回每个路由的中间件与 handler
+//
+// 路由冲突（如 `/user/:id` 与 `/user/:name`）先在一个临时的 gin.Engine 上试注册检测：
+// router 为 *gin.Engine 时，临时 Engine 中会先注册它已有的路由；router 为 *gin.RouterGroup 时，
+// gin 不提供读取所属 Engine 的方法，只能检测本批次内的冲突，与已有路由的冲突由 applyRoutes 发现，
+// 此时本批次中在它之前的路由已经注册
+func prepareRoutes(router gin.IRouter, rs []Route) ([][]gin.HandlerFunc, error) {
+	base := "/"
+	if g, ok := router.(interface{ BasePath() string }); ok {
+		base = g.BasePath()
+	}
+
+	noop := func(*gin.Context) {}
+	scratch := gin.New()
+	if e, ok := router.(*gin.Engine); ok {
+		for _, ri := range e.Routes() {
+			scratch.Handle(ri.Method, ri.Path, noop)
+		}
+	}
+
+	var errs []error
+	seen := map[string]string{} // 同一批次内的重复路由，给出冲突的方法名
+	handlers := make([][]gin.HandlerFunc, len(rs))
+	for i := range rs {
+		route := &rs[i]
+		route.FullPath = joinPath(base, route.Path)
+
+		if name, ok := seen[route.String()]; ok {
+			errs = append(errs, fmt.Errorf("%s: route %q conflicts with %s", route.Name, route.String(), name))
+			continue
+		}
+		seen[route.String()] = route.Name
+
+		full := *route
+		full.Path = route.FullPath
+		if err := handleRoute(scratch, full, []gin.HandlerFunc{noop}); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", route.Name, err))
+			continue
+		}
+
+		h, err := routeHandlers(*route)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", route.Name, err))
+			continue
+		}
+		handlers[i] = h
+	}
+	return handlers, errors.Join(errs...)
+}
+
+// applyRoutes 注册已校验的路由
+func applyRoutes(router gin.IRouter, rs []Route, handlers [][]gin.HandlerFunc) error {
+	routesMu.Lock()
+	defer routesMu.Unlock()
+
+	var errs []error
+	for i, route := range rs {
+		if err := handleRoute(router, route, handlers[i]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", route.Name, err))
+			continue
+		}
+		routes = append(routes, route)
+	}
+	return errors.Join(errs...)
+}
+
+// routeHandlers 返回路由的中间件与 handler
+func routeHandlers(route Route) ([]gin.HandlerFunc, error) {
+	middlewaresMu.RLock()
+	defer middlewaresMu.RUnlock()
+
+	var handlers []gin.HandlerFunc
+	for _, name := range route.Middlewares {
+		h, ok := middlewares[name]
+		if !ok {
+			return nil, fmt.Errorf("middleware %q is not registered", name)
+		}
+		handlers = append(handlers, h...)
+	}
+	return append(handlers, funcHandler(route.Func, route.Options)), nil
+}
+
+// handleRoute 将 gin 注册路由时的 panic 转换为 error，如重复注册或通配符冲突 `/user/:id` 与 `/user/:name`
+func handleRoute(router gin.IRouter, route Route, handlers []gin.HandlerFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("route %q: %v", route.String(), r)
+		}
+	}()
+
+	router.Handle(route.Method, route.Path, handlers...)
+	return nil
+}
+
+// RegisterObject 通过结构体（对象）注册路由，路由信息读取自请求参数 `XxxReq` 的 meta 字段：
+//
+//	type HelloGetReq struct {
+//		meta struct{} `method:"GET" path:"/hello-world" summary:"打招呼" tags:"hello" middleware:"auth,log" produces:"json,xml"`
+//	}
+//
+// 上传文件的路由可以声明请求体的大小限制与文件的 MIME 类型：`limit:"10MB" mimes:"image/png,image/*"`，
+// 方法的拦截器通过 `interceptor:"cache,audit"` 引用，见 Interceptor；处理函数的超时时间：`timeout:"3s"`
+//
+// 参考：[规范参数结构](https://goframe.org/pages/viewpage.action?pageId=116004922)
+//
+// 所有方法都会先完成校验，返回的错误会列出每一个缺少元数据、中间件未注册或路由冲突的方法，
+// 路由冲突的检测范围见 RegisterRoutes；opts 会应用到所有方法的 handler
+func RegisterObject(router gin.IRouter, object any, opts ...Option) error {
+	rs, metaErr := ObjectRoutes(object, opts...)
+	for i := range rs {
+		rs[i].Options = append(append([]Option(nil), opts...), rs[i].Options...) // meta 标签的选项优先
+	}
+	handlers, err := prepareRoutes(router, rs)
+	if err := errors.Join(metaErr, err); err != nil {
+		return err
+	}
+	return applyRoutes(router, rs, handlers)
+}
+
+// ObjectRoutes 读取结构体所有方法的 meta 标签，返回路由元数据，opts 中只有 WithNaming 与 WithInterceptors 会生效
+func ObjectRoutes(object any, opts ...Option) ([]Route, error) {
+	var (
+		rs   []Route
+		errs []error
+	)
+	err := TryObjectHandler(object, func(fn *ReqResFunc, methodName string) {
+		route, err := metaRoute(fn)
+		route.Name = objectName(object) + "." + methodName
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", route.Name, err))
+			return
+		}
+		rs = append(rs, route)
+	}, opts...)
+	return rs, errors.Join(append([]error{err}, errs...)...)
+}
+
+// metaRoute 读取请求参数 meta 字段的标签
+func metaRoute(fn Func) (Route, error) {
+	route := Route{Func: fn}
+
+	if fn.Req() == nil {
+		return route, errors.New("handler without request must be registered by Route, it has no meta field")
+	}
+	field, ok := fn.Req().FieldByName("meta")
+	if !ok {
+		return route, fmt.Errorf("request %s must contain a meta field", fn.Req())
+	}
+
+	tag := field.Tag
+	route.Method = strings.ToUpper(tag.Get("method"))
+	route.Path = tag.Get("path")
+	route.Summary = tag.Get("summary")
+	route.Tags = splitTag(tag.Get("tags"))
+	route.Middlewares = splitTag(tag.Get("middleware"))
+
+	if !validMethod(route.Method) {
+		return route, fmt.Errorf("invalid meta tag method %q of %s", tag.Get("method"), fn.Req())
+	}
+	if !strings.HasPrefix(route.Path, "/") {
+		return route, fmt.Errorf(`invalid meta tag path %q of %s, it must start with "/"`, route.Path, fn.Req())
+	}
+	return route, nil
+}
+
+// metaOptions 读取请求参数 meta 字段中构造 handler 的选项（produces、limit、mimes、timeout），在构造 ReqResFunc 与 TypedFunc 时解析，
+// 因此 RegisterObject、RegisterByName 与直接调用 Handler 都会生效，且优先于构造 handler 时传入的选项
+func metaOptions(tag reflect.StructTag) ([]Option, error) {
+	var opts []Option
+	formats, err := parseFormats(tag.Get("produces"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid meta tag produces: %w", err)
+	}
+	if len(formats) > 0 {
+		opts = append(opts, WithFormats(formats...))
+	}
+	if limit, ok := tag.Lookup("limit"); ok {
+		size, err := parseSize(limit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid meta tag limit: %w", err)
+		}
+		opts = append(opts, WithUploadLimit(size))
+	}
+	if mimes := splitTag(tag.Get("mimes")); len(mimes) > 0 {
+		opts = append(opts, WithUploadTypes(mimes...))
+	}
+	if timeout, ok := tag.Lookup("timeout"); ok {
+		d, err := parseTimeout(timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid meta tag timeout: %w", err)
+		}
+		opts = append(opts, WithTimeout(d))
+	}
+	return opts, nil
+}
+
+func validMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// splitTag 按逗号分割标签值，并去除空白
+func splitTag(tag string) []string {
+	var values []string
+	for _, v := range strings.Split(tag, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func objectName(object any) string {
+	name := fmt.Sprintf("%T", object)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+func joinPath(base, relative string) string {
+	if relative == "" {
+		return base
+	}
+
+	p := path.Join(base, relative)
+	if strings.HasSuffix(relative, "/") && !strings.HasSuffix(p, "/") {
+		return p + "/"
+	}
+	return p
+}
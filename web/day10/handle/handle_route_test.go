@@ -0,0 +1,166 @@
+package handle_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	ArticleGetReq struct {
+		meta struct{} `method:"get" path:"/article/:id" summary:"查询文章" tags:"article, public" middleware:"version"`
+		Id   int      `uri:"id"`
+	}
+	ArticleGetRes struct {
+		Id int `json:"id"`
+	}
+
+	ArticleDeleteReq struct {
+		meta struct{} `method:"DELETE" path:"/article/:id"`
+		Id   int      `uri:"id"`
+	}
+	ArticleDeleteRes struct{}
+)
+
+type Article struct{}
+
+func (c *Article) Get(ctx context.Context, req *ArticleGetReq) (res *ArticleGetRes, err error) {
+	return &ArticleGetRes{Id: req.Id}, nil
+}
+
+func (c *Article) Delete(ctx context.Context, req *ArticleDeleteReq) (res *ArticleDeleteRes, err error) {
+	return &ArticleDeleteRes{}, nil
+}
+
+func TestRegisterObject(t *testing.T) {
+	handle.RegisterMiddleware("version", func(c *gin.Context) { c.Header("X-Version", "v1") })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := handle.RegisterObject(r.Group("/api"), &Article{}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/article/3", nil))
+	if w.Code != http.StatusOK || w.Header().Get("X-Version") != "v1" || !strings.Contains(w.Body.String(), `"id":3`) {
+		t.Errorf("got %d %v %s", w.Code, w.Header(), w.Body)
+	}
+
+	var route handle.Route
+	for _, rt := range handle.Routes() {
+		if rt.Name == "Article.Get" {
+			route = rt
+		}
+	}
+	if route.String() != "GET /api/article/:id" || route.Summary != "查询文章" || strings.Join(route.Tags, "|") != "article|public" {
+		t.Errorf("got route %+v", route)
+	}
+
+	// 重复注册由 gin 检测，转换为 error
+	err := handle.RegisterObject(r.Group("/api"), &Article{})
+	if err == nil || !strings.Contains(err.Error(), "Article.Get") {
+		t.Errorf("got err %v, want route conflict", err)
+	}
+}
+
+type (
+	BadNoMetaReq struct {
+		Id int
+	}
+	BadNoMetaRes struct{}
+
+	BadFirstReq struct {
+		meta struct{} `method:"GET" path:"/bad" middleware:"missing"`
+	}
+	BadFirstRes struct{}
+
+	BadSecondReq struct {
+		meta struct{} `method:"GET" path:"/bad"`
+	}
+	BadSecondRes struct{}
+)
+
+type Bad struct{}
+
+func (c Bad) NoMeta(ctx context.Context, req *BadNoMetaReq) (res *BadNoMetaRes, err error) {
+	return nil, nil
+}
+func (c Bad) First(ctx context.Context, req *BadFirstReq) (res *BadFirstRes, err error) {
+	return nil, nil
+}
+func (c Bad) Second(ctx context.Context, req *BadSecondReq) (res *BadSecondRes, err error) {
+	return nil, nil
+}
+
+func TestRegisterObjectError(t *testing.T) {
+	r := gin.New()
+
+	err := handle.RegisterObject(r, Bad{})
+	if err == nil {
+		t.Fatal("want error")
+	}
+	for _, want := range []string{
+		"Bad.NoMeta: request handle_test.BadNoMetaReq must contain a meta field",
+		`Bad.Second: route "GET /bad" conflicts with Bad.First`,
+		`Bad.First: middleware "missing" is not registered`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should contain %q", err, want)
+		}
+	}
+
+	if routes := r.Routes(); len(routes) != 0 {
+		t.Errorf("no route should be registered, got %v", routes)
+	}
+}
+
+type (
+	WildcardIdReq struct {
+		meta struct{} `method:"GET" path:"/wildcard/:id"`
+	}
+	WildcardNameReq struct {
+		meta struct{} `method:"GET" path:"/wildcard/:name/posts"`
+	}
+	WildcardRes struct{}
+)
+
+type Wildcard struct{}
+
+func (Wildcard) ById(ctx context.Context, req *WildcardIdReq) (*WildcardRes, error) { return nil, nil }
+func (Wildcard) ByName(ctx context.Context, req *WildcardNameReq) (*WildcardRes, error) {
+	return nil, nil
+}
+
+func TestRegisterObjectWildcardConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 同一批次内的通配符冲突
+	r := gin.New()
+	err := handle.RegisterObject(r, Wildcard{})
+	if err == nil || !strings.Contains(err.Error(), "Wildcard.ByName") {
+		t.Errorf("got err %v, want wildcard conflict", err)
+	}
+	if routes := r.Routes(); len(routes) != 0 {
+		t.Errorf("no route should be registered, got %v", routes)
+	}
+
+	// 与 Engine 上已有路由的冲突
+	r = gin.New()
+	r.GET("/wildcard/:key/posts", func(*gin.Context) {})
+	if err := handle.RegisterRoutes(r,
+		handle.Route{Method: http.MethodGet, Path: "/other", Func: handle.Typed(Echo)},
+		handle.Route{Method: http.MethodGet, Path: "/wildcard/:id", Func: handle.Typed(Echo)},
+	); err == nil {
+		t.Error("want conflict with the existing route")
+	}
+	if routes := r.Routes(); len(routes) != 1 {
+		t.Errorf("only the existing route should be registered, got %v", routes)
+	}
+}
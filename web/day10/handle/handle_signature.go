@@ -0,0 +1,48 @@
+package handle
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Rule 处理函数签名的规则，用于 SignatureError
+type Rule string
+
+const (
+	RuleObject      Rule = "object"       // ObjectHandler 的参数必须是结构体或结构体指针
+	RuleFunc        Rule = "func"         // 处理函数必须是函数
+	RuleParams      Rule = "params"       // 参数为 context.Context 与可选的请求参数
+	RuleResults     Rule = "results"      // 返回值为可选的返回参数与 error
+	RuleContext     Rule = "context"      // 第一个参数必须实现 context.Context
+	RuleError       Rule = "error"        // 最后一个返回值必须实现 error
+	RuleRequest     Rule = "request"      // 请求参数必须是结构体或结构体指针
+	RuleRequestName Rule = "request-name" // 请求参数的命名规范
+	RuleResult      Rule = "result"       // 返回参数必须是结构体、结构体指针、切片、map、channel、EventStream 或 Responder
+	RuleResultName  Rule = "result-name"  // 返回参数的命名规范
+	RuleInterceptor Rule = "interceptor"  // meta 标签 interceptor 引用的拦截器必须已经注册
+	RuleIn          Rule = "in"           // 请求参数的 in 标签必须是 header、cookie、query、path 或 body
+	RuleValidate    Rule = "validate"     // 请求参数的 validate 标签必须合法，见 Validate
+	RuleDefault     Rule = "default"      // 请求参数的 default 标签必须能转换为字段的类型
+	RuleMeta        Rule = "meta"         // meta 标签中构造 handler 的选项必须合法，如 produces、limit、mimes、timeout
+)
+
+// SignatureError 处理函数的签名不符合要求，由 TryNewReqResFunc、TryObjectHandler 返回
+//
+// 同一个函数违反多条规则时，通过 errors.Join 返回所有的错误，启动时可以一次输出所有问题：
+//
+//	if err := handle.TryObjectHandler(controller.User, f); err != nil {
+//		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+//			var se *handle.SignatureError
+//			if errors.As(e, &se) { ... se.Method, se.Type, se.Rule }
+//		}
+//	}
+type SignatureError struct {
+	Method string       // 方法名，如 User.GetById，函数时为完整的函数名
+	Type   reflect.Type // 违反规则的类型：函数、参数或返回值的类型
+	Rule   Rule         // 违反的规则
+	Msg    string       // 错误描述
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("invalid handler %s: rule %s, type %v: %s", e.Method, e.Rule, e.Type, e.Msg)
+}
@@ -0,0 +1,348 @@
+package handle
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 请求参数的来源，通过 in 标签声明，参数名取自对应的标签，缺省为字段名：
+//
+//	type UserUpdateReq struct {
+//		Token string `in:"header" header:"Authorization"`
+//		Sid   string `in:"cookie" cookie:"sid"`
+//		Id    int    `in:"path" uri:"id"`
+//		Page  int    `in:"query" form:"page"`
+//		Ids   []int  `in:"query" form:"id" split:","` // ?id=1,2&id=3，没有 split 标签时不拆分
+//		Name  string `in:"body" json:"name"`
+//	}
+const (
+	InHeader = "header" // 请求头，参数名取自 header 标签
+	InCookie = "cookie" // Cookie，参数名取自 cookie 标签
+	InQuery  = "query"  // 查询参数，参数名取自 form 标签
+	InPath   = "path"   // 动态路由，参数名取自 uri 标签
+	InBody   = "body"   // 请求体，参数名取自 json 标签，由 Content-Type 对应的 Decoder 解析
+)
+
+// sourceTags 参数来源对应的参数名标签
+var sourceTags = map[string]string{
+	InHeader: "header",
+	InCookie: "cookie",
+	InQuery:  "form",
+	InPath:   "uri",
+	InBody:   "json",
+}
+
+// sourceField 声明了 in 标签的字段
+type sourceField struct {
+	index    []int  // 字段的索引，匿名字段会展开
+	in       string // 参数来源
+	name     string // 参数名
+	form     string // form 标签中的参数名，gin 解析表单时会读取，见 formDecoder
+	layout   string // time.Time 的格式，取自 time_format 标签
+	split    string // 切片与 map 的值的分隔符，取自 split 标签，为空时不拆分
+	implicit bool   // 没有 in 标签、带 form 标签的切片或 map，由 formDecoder 读取
+	upload   bool   // 没有 in 标签、带 form 标签的 Upload，由 formDecoder 读取
+}
+
+var sourcesCache sync.Map // map[reflect.Type][]sourceField
+
+// sourceFields 解析并缓存结构体中声明了 in 标签的字段
+func sourceFields(t reflect.Type) ([]sourceField, error) {
+	if cached, ok := sourcesCache.Load(t); ok {
+		return cached.([]sourceField), nil
+	}
+
+	var fields []sourceField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			nested, err := sourceFields(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			for _, sf := range nested {
+				sf.index = append([]int{i}, sf.index...)
+				fields = append(fields, sf)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		in, ok := field.Tag.Lookup("in")
+		if !ok {
+			// gin 不支持逗号分隔的切片与 name[key]=value 形式的 map，由 formDecoder 读取
+			if _, ok := field.Tag.Lookup("form"); ok && isUpload(field.Type) {
+				name := tagName(field, "form")
+				fields = append(fields, sourceField{index: []int{i}, in: InBody, name: name, form: name, upload: true})
+			} else if ok && isCollection(field.Type) {
+				name := tagName(field, "form")
+				fields = append(fields, sourceField{index: []int{i}, in: InQuery, name: name, form: name, layout: field.Tag.Get("time_format"), split: field.Tag.Get("split"), implicit: true})
+			}
+			continue
+		}
+		if _, ok := sourceTags[in]; !ok {
+			return nil, fmt.Errorf("invalid in tag %q of %s.%s", in, t, field.Name)
+		}
+		fields = append(fields, sourceField{index: []int{i}, in: in, name: sourceName(field, in), form: tagName(field, "form"), layout: field.Tag.Get("time_format"), split: field.Tag.Get("split")})
+	}
+
+	sourcesCache.Store(t, fields)
+	return fields, nil
+}
+
+// isCollection 类型是否为切片（[]byte 与上传的文件除外）或 map
+func isCollection(t reflect.Type) bool {
+	if isFile(t) {
+		return false
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Map || t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+// sourceName 返回字段在参数来源 in 中的参数名
+func sourceName(field reflect.StructField, in string) string {
+	return tagName(field, sourceTags[in])
+}
+
+// bindSources 按 in 标签为字段赋值，在请求体解析之后执行，因此：
+//
+//  1. in 为 header、cookie、query、path 的字段只会读取对应的来源，请求体中的同名参数会被忽略
+//  2. in 为 body 的字段只会读取请求体，没有请求体（如 GET 请求）时为零值
+//  3. 没有 in 标签的字段保持原有的行为：动态路由 + 请求体（或 query）
+func bindSources(c *gin.Context, point any, hasBody bool) error {
+	v := reflect.ValueOf(point)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields, err := sourceFields(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, sf := range fields {
+		field := v.FieldByIndex(sf.index)
+		if sf.implicit || sf.upload {
+			continue
+		}
+		if sf.in == InBody {
+			if !hasBody {
+				field.SetZero()
+			}
+			continue
+		}
+
+		field.SetZero()
+		values, ok := sourceValues(c, sf)
+		if !ok {
+			continue
+		}
+		if err := setValues(field, values, sf.layout, sf.split); err != nil {
+			return badRequest(fmt.Errorf("invalid %s parameter %q: %w", sf.in, sf.name, err))
+		}
+	}
+	return nil
+}
+
+// sourceValues 从请求中读取参数，第二个返回值表示参数是否存在
+func sourceValues(c *gin.Context, sf sourceField) ([]string, bool) {
+	switch sf.in {
+	case InHeader:
+		values := c.Request.Header.Values(sf.name)
+		return values, len(values) > 0
+	case InCookie:
+		cookie, err := c.Cookie(sf.name)
+		return []string{cookie}, err == nil
+	case InQuery:
+		return formValues(c.Request.URL.Query(), sf.name)
+	case InPath:
+		value, ok := c.Params.Get(sf.name)
+		return []string{value}, ok
+	}
+	return nil, false
+}
+
+// formValues 读取 query 或表单中的参数 name，map 以 name[key]=value 传递时返回 key=value 形式的值
+func formValues(form url.Values, name string) ([]string, bool) {
+	values, ok := form[name]
+	values = append([]string(nil), values...)
+	for key, vs := range form {
+		k, found := strings.CutPrefix(key, name+"[")
+		if !found || !strings.HasSuffix(k, "]") {
+			continue
+		}
+		for _, v := range vs {
+			values = append(values, strings.TrimSuffix(k, "]")+"="+v)
+		}
+		ok = true
+	}
+	return values, ok
+}
+
+// setValues 将字符串转换为字段的类型：
+//
+//   - 指针：分配内存后赋值，参数不存在时保持 nil，用于区分“未传”与零值
+//   - 切片：读取所有的值，如 ?id=1&id=2 为 [1 2]；sep 不为空时再按 sep 拆分，
+//     如声明了 `split:","` 时 ?id=1,2&id=3 为 [1 2 3]
+//   - map：值的格式为 key=value，如 ?sort=name=asc&sort=age=desc 或 ?sort[name]=asc，同样按 sep 拆分
+//   - 其他类型只读取第一个值，time.Time 的格式为 layout，缺省为 RFC 3339
+//
+// 请求中的值只在字段声明了 split 标签时拆分，避免拆开本身包含逗号的值；default 标签的值总是按逗号拆分
+func setValues(v reflect.Value, values []string, layout, sep string) error {
+	if v.Kind() == reflect.Pointer {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setValues(ptr.Elem(), values, layout, sep); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+
+	switch {
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8:
+		items := splitValues(values, sep)
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValues(slice.Index(i), []string{item}, layout, ""); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case v.Kind() == reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		for _, item := range splitValues(values, sep) {
+			k, val, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("invalid map entry %q, want key=value", item)
+			}
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := setString(key, k, layout); err != nil {
+				return err
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setValues(elem, []string{val}, layout, ""); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, elem)
+		}
+		v.Set(m)
+		return nil
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+	return setString(v, values[0], layout)
+}
+
+// splitValues 按 sep 拆分所有的值并去掉首尾的空白，sep 为空时不拆分；忽略空值
+func splitValues(values []string, sep string) []string {
+	var items []string
+	for _, value := range values {
+		if sep == "" {
+			if value != "" {
+				items = append(items, value)
+			}
+			continue
+		}
+		for _, item := range strings.Split(value, sep) {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// setString 将字符串转换为基础类型、time.Time 与 time.Duration
+func setString(v reflect.Value, s, layout string) error {
+	switch v.Type() {
+	case timeType:
+		t, err := parseTime(strings.TrimSpace(s), layout)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Slice: // []byte
+		v.SetBytes([]byte(s))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// parseTime 按 layout 解析时间，layout 与 gin 的 time_format 标签一致，另支持 unix、unixmilli
+func parseTime(s, layout string) (time.Time, error) {
+	switch layout {
+	case "":
+		return time.Parse(time.RFC3339, s)
+	case "unix", "unixmilli":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if layout == "unix" {
+			return time.Unix(n, 0), nil
+		}
+		return time.UnixMilli(n), nil
+	}
+	return time.Parse(layout, s)
+}
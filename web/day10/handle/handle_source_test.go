@@ -0,0 +1,131 @@
+package handle_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	SourceReq struct {
+		Token string `in:"header" header:"Authorization" json:"token"`
+		Sid   string `in:"cookie" cookie:"sid" json:"sid"`
+		Id    int    `in:"path" uri:"id" json:"id"`
+		Page  []int  `in:"query" form:"page" json:"page"`
+		Name  string `in:"body" json:"name"`
+	}
+	SourceRes = SourceReq
+)
+
+func Source(ctx context.Context, req *SourceReq) (res *SourceRes, err error) {
+	return req, nil
+}
+
+func TestSources(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Handle("GET", "/users/:id", handle.Typed(Source).Handler())
+	r.Handle("POST", "/users/:id", handle.Typed(Source).Handler())
+
+	tests := []struct {
+		name, method, target, body string
+		status                     int
+		want                       string
+	}{
+		{
+			name:   "all sources",
+			method: "POST", target: "/users/7?page=1&page=2", body: `{"name":"gee"}`,
+			status: 200, want: `{"token":"Bearer t","sid":"s1","id":7,"page":[1,2],"name":"gee"}`,
+		},
+		{
+			// 请求体中的同名参数不会覆盖 header、cookie、path、query
+			name:   "body ignored for explicit sources",
+			method: "POST", target: "/users/7?page=3", body: `{"token":"x","sid":"x","id":99,"page":[9],"name":"gee"}`,
+			status: 200, want: `{"token":"Bearer t","sid":"s1","id":7,"page":[3],"name":"gee"}`,
+		},
+		{
+			// GET 请求没有请求体，query 中的 body 参数会被忽略
+			name:   "query ignored for body source",
+			method: "GET", target: "/users/7?name=gee&Token=x&Id=99",
+			status: 200, want: `{"token":"Bearer t","sid":"s1","id":7,"page":null,"name":""}`,
+		},
+		{
+			name:   "invalid query",
+			method: "GET", target: "/users/7?page=x",
+			status: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			req.Header.Set("Authorization", "Bearer t")
+			req.AddCookie(&http.Cookie{Name: "sid", Value: "s1"})
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.status, w.Body)
+			}
+			if tt.want == "" {
+				return
+			}
+			var resp struct {
+				Data json.RawMessage `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if string(resp.Data) != tt.want {
+				t.Errorf("data = %s, want %s", resp.Data, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourcesOpenAPI(t *testing.T) {
+	doc := handle.OpenAPI(handle.OpenAPIInfo{}, []handle.Route{
+		{Method: "POST", Path: "/users/:id", Func: handle.Typed(Source)},
+	})
+	op := doc.Paths["/users/{id}"]["post"]
+
+	var got []string
+	for _, p := range op.Parameters {
+		got = append(got, p.In+":"+p.Name)
+	}
+	want := "header:Authorization cookie:sid path:id query:page"
+	if strings.Join(got, " ") != want {
+		t.Errorf("parameters = %v, want %s", got, want)
+	}
+}
+
+type BadSourceReq struct {
+	Token string `in:"headers" header:"Authorization"`
+}
+
+func TestSourcesInvalid(t *testing.T) {
+	// in 标签在构造时检查，而不是等到请求时返回 400 或 500
+	_, err := handle.TryNewReqResFunc(func(ctx context.Context, req *BadSourceReq) error { return nil })
+	var se *handle.SignatureError
+	if !errors.As(err, &se) || se.Rule != handle.RuleIn || !strings.Contains(se.Msg, `invalid in tag "headers"`) {
+		t.Errorf("got %v, want rule %s", err, handle.RuleIn)
+	}
+
+	defer func() {
+		if se, ok := recover().(*handle.SignatureError); !ok || se.Rule != handle.RuleIn {
+			t.Errorf("Typed should panic with rule %s, got %v", handle.RuleIn, se)
+		}
+	}()
+	handle.Typed(func(ctx context.Context, req *BadSourceReq) (*EchoRes, error) { return nil, nil })
+}
@@ -0,0 +1,188 @@
+package handle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Event Server-Sent Events 的一个事件
+type Event struct {
+	Id    string        // 事件 id，客户端重连时通过请求头 Last-Event-ID 带回
+	Event string        // 事件类型，为空时客户端触发 message 事件
+	Data  any           // 事件数据，经过 Envelope 包装后以 JSON 编码
+	Retry time.Duration // 客户端重连的间隔，为 0 时不发送
+}
+
+// EventStream 以 Server-Sent Events（text/event-stream）返回的数据流，
+// 处理函数的返回参数为 EventStream 或只读 channel 时，以 Server-Sent Events 返回：
+//
+//	type TeamWatchReq struct {
+//		Id          int    `uri:"id"`
+//		LastEventId string `in:"header" header:"Last-Event-ID"` // 断线重连时从这个 id 之后继续发送
+//	}
+//
+//	func (t *team) Watch(ctx context.Context, req *TeamWatchReq) (handle.EventStream, error)
+//	func (t *team) Watch(ctx context.Context, req *TeamWatchReq) (<-chan handle.Event, error)
+//	func (t *team) Watch(ctx context.Context, req *TeamWatchReq) (<-chan *TeamWatchRes, error)
+//
+// channel 的元素为 Event 时原样发送，为 error 时发送 error 事件，其他类型作为 Event.Data；
+// channel 关闭后结束响应，生产者应在客户端断开后停止发送，见 EventStream
+//
+// Stream 通过 send 依次发送事件，返回后结束响应；客户端断开连接时 ctx 被取消，send 返回 ctx.Err()。
+// Stream 返回的错误会以 error 事件发送，data 为 Envelope 包装后的错误
+type EventStream interface {
+	Stream(ctx context.Context, send func(Event) error) error
+}
+
+// EventStreamFunc 函数形式的 EventStream
+type EventStreamFunc func(ctx context.Context, send func(Event) error) error
+
+func (f EventStreamFunc) Stream(ctx context.Context, send func(Event) error) error {
+	return f(ctx, send)
+}
+
+var eventStreamType = reflect.TypeOf((*EventStream)(nil)).Elem()
+
+// isEventSource 类型 t 是否为 EventStream 或只读 channel
+func isEventSource(t reflect.Type) bool {
+	if t.Kind() == reflect.Chan {
+		return t.ChanDir()&reflect.RecvDir != 0
+	}
+	return t.Implements(eventStreamType)
+}
+
+// WithHeartbeat 设置 Server-Sent Events 的心跳间隔，默认为 15s，为 0 时不发送心跳
+func WithHeartbeat(interval time.Duration) Option {
+	return func(o *options) { o.heartbeat = interval }
+}
+
+const mimeEventStream = "text/event-stream"
+
+// eventSource 返回 data 对应的 channel，EventStream 会在新的 goroutine 中运行，ctx 取消时结束
+func eventSource(ctx context.Context, data any) (ch reflect.Value, errc <-chan error, ok bool) {
+	if data == nil {
+		return reflect.Value{}, nil, false
+	}
+	if stream, isStream := data.(EventStream); isStream {
+		events := make(chan Event)
+		errs := make(chan error, 1)
+		go func() {
+			var err error
+			defer func() {
+				errs <- err
+				close(events)
+			}()
+			defer recoverPanic(fmt.Sprintf("%T", stream), &err)
+
+			err = stream.Stream(ctx, func(e Event) error {
+				select {
+				case events <- e:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+		}()
+		return reflect.ValueOf(events), errs, true
+	}
+
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Chan || v.Type().ChanDir()&reflect.RecvDir == 0 {
+		return reflect.Value{}, nil, false
+	}
+	return v, nil, true
+}
+
+// serveEvents 以 text/event-stream 发送 ch 中的事件，直到 ch 关闭或客户端断开连接
+func serveEvents(c *gin.Context, o *options, ch reflect.Value, errc <-chan error) {
+	header := c.Writer.Header()
+	header.Set("Content-Type", mimeEventStream)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // 禁用 nginx 的缓冲
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	var heartbeat <-chan time.Time
+	if o.heartbeat > 0 {
+		ticker := time.NewTicker(o.heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: ch},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(heartbeat)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c.Request.Context().Done())},
+	}
+	for {
+		chosen, recv, ok := reflect.Select(cases)
+		switch chosen {
+		case 0:
+			if !ok {
+				if errc != nil {
+					if err := <-errc; err != nil && err != io.EOF && c.Request.Context().Err() == nil {
+						writeEvent(c, o, Event{Event: "error", Data: err})
+					}
+				}
+				return
+			}
+			writeEvent(c, o, toEvent(recv.Interface()))
+		case 1:
+			_, _ = io.WriteString(c.Writer, ": ping\n\n")
+		case 2:
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// toEvent 将 channel 中的元素转换为 Event
+func toEvent(v any) Event {
+	switch e := v.(type) {
+	case Event:
+		return e
+	case *Event:
+		return *e
+	case error:
+		return Event{Event: "error", Data: e}
+	}
+	return Event{Data: v}
+}
+
+// writeEvent 写入一个事件，Data 为 error 时以 Envelope.Failure 包装，否则以 Envelope.Success 包装
+func writeEvent(c *gin.Context, o *options, e Event) {
+	var body any
+	if err, ok := e.Data.(error); ok {
+		_, body = o.failure(c, err)
+	} else {
+		_, body = o.envelope.Success(c, e.Data)
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		_ = c.Error(err)
+		_, failure := o.envelope.Failure(c, localize(c, ToError(err)))
+		data, _ = json.Marshal(failure)
+	}
+
+	var b strings.Builder
+	if e.Id != "" {
+		fmt.Fprintf(&b, "id: %s\n", strings.ReplaceAll(e.Id, "\n", ""))
+	}
+	if e.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", strings.ReplaceAll(e.Event, "\n", ""))
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", e.Retry.Milliseconds())
+	}
+	fmt.Fprintf(&b, "data: %s\n\n", data)
+	_, _ = io.WriteString(c.Writer, b.String())
+}
@@ -0,0 +1,143 @@
+package handle_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	WatchReq struct {
+		LastEventId string `in:"header" header:"Last-Event-ID"`
+	}
+	WatchRes struct {
+		Seq int `json:"seq"`
+	}
+)
+
+func events(t *testing.T, fn any, opts ...handle.Option) *httptest.Server {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/watch", handle.NewReqResFunc(fn).Handler(opts...))
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, url string, header ...string) *http.Response {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestEventsChannel(t *testing.T) {
+	srv := events(t, func(ctx context.Context, req *WatchReq) (<-chan *WatchRes, error) {
+		ch := make(chan *WatchRes, 2)
+		ch <- &WatchRes{Seq: 1}
+		ch <- &WatchRes{Seq: 2}
+		close(ch)
+		return ch, nil
+	})
+
+	resp := get(t, srv.URL+"/watch")
+	body, _ := io.ReadAll(resp.Body)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	want := "data: {\"code\":200,\"msg\":\"\",\"data\":{\"seq\":1}}\n\n" +
+		"data: {\"code\":200,\"msg\":\"\",\"data\":{\"seq\":2}}\n\n"
+	if string(body) != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestEventsResume(t *testing.T) {
+	srv := events(t, func(ctx context.Context, req *WatchReq) (handle.EventStream, error) {
+		last, _ := strconv.Atoi(req.LastEventId)
+		return handle.EventStreamFunc(func(ctx context.Context, send func(handle.Event) error) error {
+			for seq := last + 1; seq <= 3; seq++ {
+				if err := send(handle.Event{Id: strconv.Itoa(seq), Event: "seq", Data: WatchRes{Seq: seq}}); err != nil {
+					return err
+				}
+			}
+			return errors.New("stream broken")
+		}), nil
+	})
+
+	resp := get(t, srv.URL+"/watch", "Last-Event-ID", "2")
+	body, _ := io.ReadAll(resp.Body)
+	want := "id: 3\nevent: seq\ndata: {\"code\":200,\"msg\":\"\",\"data\":{\"seq\":3}}\n\n" +
+		"event: error\ndata: {\"code\":500,\"msg\":\"Internal Server Error\",\"data\":null}\n\n"
+	if string(body) != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestEventsHeartbeatAndDisconnect(t *testing.T) {
+	done := make(chan error, 1)
+	srv := events(t, func(ctx context.Context, req *WatchReq) (handle.EventStream, error) {
+		return handle.EventStreamFunc(func(ctx context.Context, send func(handle.Event) error) error {
+			<-ctx.Done()
+			done <- ctx.Err()
+			return ctx.Err()
+		}), nil
+	}, handle.WithHeartbeat(10*time.Millisecond))
+
+	resp := get(t, srv.URL+"/watch")
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != ": ping\n" {
+		t.Fatalf("line = %q, err = %v", line, err)
+	}
+	resp.Body.Close()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("ctx.Err() = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("stream was not cancelled after the client disconnected")
+	}
+}
+
+func TestEventsNotAcceptable(t *testing.T) {
+	var called atomic.Bool
+	srv := events(t, func(ctx context.Context, req *WatchReq) (*WatchRes, error) {
+		called.Store(true)
+		return &WatchRes{Seq: 1}, nil
+	})
+
+	resp := get(t, srv.URL+"/watch")
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusNotAcceptable || !strings.Contains(string(body), `"code":406`) {
+		t.Errorf("status = %d, body = %s", resp.StatusCode, body)
+	}
+	// 返回参数不是事件流，在调用处理函数之前返回 406
+	if called.Load() {
+		t.Error("handler was called")
+	}
+}
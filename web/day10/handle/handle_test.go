@@ -3,6 +3,7 @@ package handle_test
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -66,6 +67,35 @@ func TestObjectHandler(t *testing.T) {
 	})
 }
 
+func TestRegisterObjectHello(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	// 根据结构体的 tag 注册路由
+	if err := handle.RegisterObject(r.Group("/gf"), Hello{}); err != nil {
+		t.Fatal(err)
+	}
+	// 根据方法名注册路由
+	if err := handle.RegisterByName(r.Group("/iris"), Hello{}, handle.NameOption{Split: handle.SplitSlash}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct{ method, target string }{
+		{http.MethodGet, "/gf/hello-world"},
+		{http.MethodPost, "/gf/hello-world"},
+		{http.MethodGet, "/iris/hello/world"},
+		{http.MethodPost, "/iris/hello/world"},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(`{"Name":"gee","Age":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s %s: got %d %s", tt.method, tt.target, w.Code, w.Body)
+		}
+	}
+}
+
 type (
 	HelloGetReq struct {
 		meta struct{} `method:"GET" path:"/hello-world" `
@@ -0,0 +1,106 @@
+package handle
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Func 是 ReqResFunc 与 TypedFunc 的公共接口，用于路由注册、文档生成等需要类型信息的场景
+type Func interface {
+	DecodeFunc() DecodeFunc
+	Req() reflect.Type // 请求参数的结构体类型，没有请求参数时为 nil
+	Res() reflect.Type // 返回参数的类型，没有返回数据时为 nil
+}
+
+var (
+	_ Func = (*ReqResFunc)(nil)
+	_ Func = (*TypedFunc[struct{}, struct{}])(nil)
+)
+
+// TypedFunc 基于泛型实现的 ReqResFunc
+//
+// 函数签名由编译器校验，不会在运行时 panic，调用时也不需要经过 reflect.Value.Call
+type TypedFunc[Req, Res any] struct {
+	fn func(ctx context.Context, req *Req) (res *Res, err error)
+
+	name         string            // 函数的完整名称，见 Invocation.Name
+	meta         reflect.StructTag // 请求参数 meta 字段的标签
+	interceptors []Interceptor     // 全局与方法的拦截器
+	opts         []Option          // meta 标签声明的选项，见 metaOptions
+}
+
+// Typed 返回 TypedFunc，用法：
+//
+//	r.GET("/team/:id/users", handle.Typed(controller.Team.GetUsers).Handler())
+//
+// opts 中只有 WithInterceptors 会生效；请求参数的标签不合法时会触发 *SignatureError 的 panic，
+// 如 meta 标签引用了未注册的拦截器、in 标签不是已知的来源、validate 标签的规则未知、default 标签的默认值无法转换为字段的类型
+func Typed[Req, Res any](fn func(ctx context.Context, req *Req) (res *Res, err error), opts ...Option) *TypedFunc[Req, Res] {
+	f := &TypedFunc[Req, Res]{fn: fn, name: funcName(reflect.ValueOf(fn))}
+
+	meta, ics, err := metaInterceptors(f.Req())
+	if err != nil {
+		panic(&SignatureError{Method: f.name, Type: f.Req(), Rule: RuleInterceptor, Msg: err.Error()})
+	}
+	f.meta = meta
+	f.interceptors = append(append([]Interceptor(nil), newOptions(opts).interceptors...), ics...)
+	if f.opts, err = metaOptions(meta); err != nil {
+		panic(&SignatureError{Method: f.name, Type: f.Req(), Rule: RuleMeta, Msg: err.Error()})
+	}
+	if req := f.Req(); req.Kind() == reflect.Struct {
+		if _, err := sourceFields(req); err != nil {
+			panic(&SignatureError{Method: f.name, Type: req, Rule: RuleIn, Msg: err.Error()})
+		}
+		if err := checkRules(req); err != nil {
+			panic(&SignatureError{Method: f.name, Type: req, Rule: RuleValidate, Msg: err.Error()})
+		}
+		if _, err := defaultFields(req); err != nil {
+			panic(&SignatureError{Method: f.name, Type: req, Rule: RuleDefault, Msg: err.Error()})
+		}
+	}
+	return f
+}
+
+// Call 解析请求参数，经过拦截器后调用处理函数，panic 会转换为 *PanicError 返回
+func (f *TypedFunc[Req, Res]) Call(ctx context.Context, decode func(point any) error) (res any, err error) {
+	defer recoverPanic(f.name, &err)
+
+	req := new(Req)
+	if err := decode(req); err != nil {
+		return nil, err
+	}
+	if len(f.interceptors) == 0 {
+		return f.invoke(ctx, req)
+	}
+
+	inv := &Invocation{Name: f.name, Meta: f.meta, Req: req}
+	return intercept(ctx, inv, f.interceptors, func(ctx context.Context) (any, error) {
+		req, ok := inv.Req.(*Req)
+		if !ok {
+			return nil, fmt.Errorf("%s: request %T passed by interceptor is not %T", f.name, inv.Req, req)
+		}
+		return f.invoke(ctx, req)
+	})
+}
+
+func (f *TypedFunc[Req, Res]) invoke(ctx context.Context, req *Req) (any, error) {
+	res, err := f.fn(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (f *TypedFunc[Req, Res]) DecodeFunc() DecodeFunc { return f.Call }
+
+func (f *TypedFunc[Req, Res]) Handler(opts ...Option) gin.HandlerFunc {
+	return funcHandler(f, opts)
+}
+
+func (f *TypedFunc[Req, Res]) metaOptions() []Option { return f.opts }
+
+func (f *TypedFunc[Req, Res]) Req() reflect.Type { return reflect.TypeOf((*Req)(nil)).Elem() }
+func (f *TypedFunc[Req, Res]) Res() reflect.Type { return reflect.TypeOf((*Res)(nil)) }
@@ -0,0 +1,71 @@
+package handle_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"gee/web/day10/handle"
+)
+
+type (
+	EchoReq struct {
+		Id   int    `uri:"id"`
+		Name string `form:"name"`
+	}
+	EchoRes struct {
+		Id   int    `json:"id"`
+		Name string `json:"name"`
+	}
+)
+
+func Echo(ctx context.Context, req *EchoReq) (res *EchoRes, err error) {
+	return &EchoRes{Id: req.Id, Name: req.Name}, nil
+}
+
+func TestTyped(t *testing.T) {
+	f := handle.Typed(Echo)
+
+	if f.Req() != reflect.TypeOf(EchoReq{}) || f.Res() != reflect.TypeOf(&EchoRes{}) {
+		t.Errorf("got Req() = %v, Res() = %v", f.Req(), f.Res())
+	}
+
+	// 与 ReqResFunc 的类型信息保持一致
+	g := handle.NewReqResFunc(Echo)
+	if f.Req() != g.Req() || f.Res() != g.Res() {
+		t.Errorf("Typed = (%v, %v), NewReqResFunc = (%v, %v)", f.Req(), f.Res(), g.Req(), g.Res())
+	}
+
+	status, resp := serve(t, http.MethodGet, "/echo/:id", "/echo/7?name=gee", f.Handler())
+	data, _ := resp.Data.(map[string]any)
+	if status != http.StatusOK || resp.Code != handle.CodeOK || data["id"] != 7.0 || data["name"] != "gee" {
+		t.Errorf("got status = %d, resp = %+v", status, resp)
+	}
+}
+
+func decodeEcho(point any) error {
+	req := point.(*EchoReq)
+	req.Id, req.Name = 7, "gee"
+	return nil
+}
+
+func BenchmarkReqResFunc(b *testing.B) {
+	f := handle.NewReqResFunc(Echo).DecodeFunc()
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = f(ctx, decodeEcho)
+	}
+}
+
+func BenchmarkTyped(b *testing.B) {
+	f := handle.Typed(Echo).DecodeFunc()
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = f(ctx, decodeEcho)
+	}
+}
@@ -0,0 +1,250 @@
+package handle
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Upload 上传的文件，可以像 io.Reader 一样直接读取，第一次读取时打开文件：
+//
+//	type AvatarUploadReq struct {
+//		meta   struct{}                `method:"POST" path:"/avatar" limit:"2MB" mimes:"image/png,image/jpeg"`
+//		Avatar *handle.Upload          `form:"avatar" validate:"required"`
+//		Photos []*multipart.FileHeader `form:"photos"`
+//	}
+//
+//	_, err := io.Copy(dst, req.Avatar)
+//
+// 请求参数也可以直接使用 *multipart.FileHeader 或 []*multipart.FileHeader。
+// multipart 表单保存在磁盘上的临时文件会在处理函数返回后删除，Upload 会在此之前关闭；
+// 字段或请求参数声明为值类型时，复制出的 Upload 共享同一个打开的文件，同样会被关闭
+type Upload struct {
+	Filename    string `form:"-" json:"filename"`    // 客户端提供的文件名
+	Size        int64  `form:"-" json:"size"`        // 文件大小
+	ContentType string `form:"-" json:"contentType"` // 客户端声明的 Content-Type
+
+	header *multipart.FileHeader
+	file   *uploadFile // 打开的文件，复制 Upload 时共享
+}
+
+type uploadFile struct{ multipart.File }
+
+// Read 读取文件内容
+func (u *Upload) Read(p []byte) (int, error) {
+	if u.header == nil || u.file == nil {
+		return 0, io.EOF
+	}
+	if u.file.File == nil {
+		f, err := u.header.Open()
+		if err != nil {
+			return 0, err
+		}
+		u.file.File = f
+	}
+	return u.file.Read(p)
+}
+
+// Close 关闭已经打开的文件
+func (u *Upload) Close() error {
+	if u.file == nil || u.file.File == nil {
+		return nil
+	}
+	err := u.file.File.Close()
+	u.file.File = nil
+	return err
+}
+
+// FileHeader 返回原始的 *multipart.FileHeader
+func (u *Upload) FileHeader() *multipart.FileHeader { return u.header }
+
+var (
+	uploadType     = reflect.TypeOf(Upload{})
+	fileHeaderType = reflect.TypeOf(multipart.FileHeader{})
+)
+
+// isFile 类型是否为上传的文件：*multipart.FileHeader、Upload 及其切片
+func isFile(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t == uploadType || t == fileHeaderType
+}
+
+// isUpload 类型是否为 Upload 及其切片
+func isUpload(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t == uploadType
+}
+
+// uploadsKey 保存请求中创建的 Upload，处理函数返回后关闭
+const uploadsKey = "handle.uploads"
+
+// bindFiles 为 Upload 类型的字段赋值，*multipart.FileHeader 由 gin 的 binding 处理
+func bindFiles(c *gin.Context, v reflect.Value, sf sourceField, form map[string][]*multipart.FileHeader) {
+	files := form[sf.name]
+	if len(files) == 0 {
+		return
+	}
+
+	newUpload := func(fh *multipart.FileHeader) reflect.Value {
+		u := &Upload{Filename: fh.Filename, Size: fh.Size, ContentType: fh.Header.Get("Content-Type"), header: fh, file: &uploadFile{}}
+		uploads, _ := c.Get(uploadsKey)
+		list, _ := uploads.([]*Upload)
+		c.Set(uploadsKey, append(list, u))
+		return reflect.ValueOf(u)
+	}
+
+	field := v.FieldByIndex(sf.index)
+	switch {
+	case field.Type() == reflect.PointerTo(uploadType):
+		field.Set(newUpload(files[0]))
+	case field.Type() == uploadType:
+		field.Set(newUpload(files[0]).Elem())
+	case field.Kind() == reflect.Slice && field.Type().Elem() == reflect.PointerTo(uploadType):
+		slice := reflect.MakeSlice(field.Type(), len(files), len(files))
+		for i, fh := range files {
+			slice.Index(i).Set(newUpload(fh))
+		}
+		field.Set(slice)
+	case field.Kind() == reflect.Slice && field.Type().Elem() == uploadType:
+		slice := reflect.MakeSlice(field.Type(), len(files), len(files))
+		for i, fh := range files {
+			slice.Index(i).Set(newUpload(fh).Elem())
+		}
+		field.Set(slice)
+	}
+}
+
+// cleanupUploads 关闭 Upload 并删除 multipart 表单的临时文件
+func cleanupUploads(c *gin.Context) {
+	if uploads, ok := c.Get(uploadsKey); ok {
+		for _, u := range uploads.([]*Upload) {
+			_ = u.Close()
+		}
+	}
+	if form := c.Request.MultipartForm; form != nil {
+		if err := form.RemoveAll(); err != nil {
+			_ = c.Error(err)
+		}
+	}
+}
+
+// WithUploadLimit 设置请求体的最大字节数，超出时返回 413；也可以在 meta 标签中声明：`limit:"10MB"`
+func WithUploadLimit(limit int64) Option {
+	return func(o *options) { o.uploadLimit = limit }
+}
+
+// WithUploadTypes 设置上传文件允许的 MIME 类型，支持 image/* 形式的通配符，不符合时返回 415；
+// 也可以在 meta 标签中声明：`mimes:"image/png,image/jpeg"`
+//
+// 客户端声明的 Content-Type 与根据文件内容识别的类型（http.DetectContentType）都需要符合，
+// 内容无法识别（application/octet-stream、text/plain）时只校验声明的类型；
+// 识别的类型较宽泛，如 docx 识别为 application/zip、csv 识别为 text/plain，需要一并允许
+func WithUploadTypes(mimes ...string) Option {
+	return func(o *options) { o.uploadTypes = mimes }
+}
+
+// parseSize 解析 meta 标签中的 limit，如 512、512KB、10MB、1GB，按 1024 换算
+func parseSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+
+	s = strings.ToUpper(strings.TrimSpace(s))
+	unit := int64(1)
+	for _, u := range units {
+		if n, ok := strings.CutSuffix(s, u.suffix); ok {
+			s, unit = strings.TrimSpace(n), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * unit, nil
+}
+
+var errRequestEntityTooLarge = NewError(CodeRequestEntityTooLarge, http.StatusRequestEntityTooLarge, "")
+
+// limitBody 限制请求体的大小
+func (o *options) limitBody(c *gin.Context) {
+	if o.uploadLimit > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, o.uploadLimit)
+	}
+}
+
+// tooLarge 请求体超出 limitBody 的限制时返回 413
+func tooLarge(err error) (*Error, bool) {
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		return errRequestEntityTooLarge.Wrap(err), true
+	}
+	return nil, false
+}
+
+// checkUploadTypes 校验所有上传文件声明的 Content-Type 与识别的内容类型
+func (o *options) checkUploadTypes(c *gin.Context) error {
+	form := c.Request.MultipartForm
+	if len(o.uploadTypes) == 0 || form == nil {
+		return nil
+	}
+	for name, files := range form.File {
+		for _, fh := range files {
+			contentType, _, _ := mime.ParseMediaType(fh.Header.Get("Content-Type"))
+			if !allowedType(contentType, o.uploadTypes) {
+				msg := fmt.Sprintf("file %q of %q: content type %q is not allowed", fh.Filename, name, contentType)
+				return NewError(CodeUnsupportedMediaType, http.StatusUnsupportedMediaType, msg)
+			}
+
+			detected, err := detectType(fh)
+			if err != nil {
+				return err
+			}
+			if detected != "application/octet-stream" && detected != "text/plain" && !allowedType(detected, o.uploadTypes) {
+				msg := fmt.Sprintf("file %q of %q: content %q is not allowed", fh.Filename, name, detected)
+				return NewError(CodeUnsupportedMediaType, http.StatusUnsupportedMediaType, msg)
+			}
+		}
+	}
+	return nil
+}
+
+// detectType 根据文件的前 512 字节识别内容类型，不含参数
+func detectType(fh *multipart.FileHeader) (string, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	return contentType, nil
+}
+
+func allowedType(contentType string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(contentType)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,305 @@
+package handle_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	UploadReq struct {
+		meta        struct{}                `method:"POST" path:"/upload" limit:"1KB" mimes:"text/*"`
+		Title       string                  `form:"title"`
+		Doc         *handle.Upload          `form:"doc" validate:"required"`
+		Attachments []*multipart.FileHeader `form:"attachments"`
+	}
+	UploadRes struct {
+		Title       string `json:"title"`
+		Filename    string `json:"filename"`
+		Content     string `json:"content"`
+		Attachments int    `json:"attachments"`
+	}
+	BigUploadReq struct {
+		meta struct{}       `method:"POST" path:"/big"`
+		File *handle.Upload `form:"file"`
+	}
+	BigUploadRes struct {
+		TempFiles int `json:"tempFiles"`
+	}
+)
+
+type Uploads struct{}
+
+func (Uploads) Upload(ctx context.Context, req *UploadReq) (*UploadRes, error) {
+	content, err := io.ReadAll(req.Doc)
+	if err != nil {
+		return nil, err
+	}
+	return &UploadRes{Title: req.Title, Filename: req.Doc.Filename, Content: string(content), Attachments: len(req.Attachments)}, nil
+}
+
+func (Uploads) Big(ctx context.Context, req *BigUploadReq) (*BigUploadRes, error) {
+	n, _ := io.Copy(io.Discard, req.File)
+	entries, _ := os.ReadDir(os.TempDir())
+	if n != req.File.Size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return &BigUploadRes{TempFiles: len(entries)}, nil
+}
+
+type part struct {
+	field, filename, contentType, content string
+}
+
+func multipartBody(parts ...part) (string, *bytes.Buffer) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, p := range parts {
+		if p.filename == "" {
+			_ = mw.WriteField(p.field, p.content)
+			continue
+		}
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Disposition", `form-data; name="`+p.field+`"; filename="`+p.filename+`"`)
+		h.Set("Content-Type", p.contentType)
+		w, _ := mw.CreatePart(h)
+		_, _ = io.WriteString(w, p.content)
+	}
+	_ = mw.Close()
+	return mw.FormDataContentType(), &body
+}
+
+func TestUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := handle.RegisterObject(r, Uploads{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		parts  []part
+		status int
+		want   string
+	}{
+		{
+			name: "ok",
+			parts: []part{
+				{field: "title", content: "notes"},
+				{field: "doc", filename: "a.txt", contentType: "text/plain", content: "hello"},
+				{field: "attachments", filename: "b.txt", contentType: "text/plain", content: "b"},
+				{field: "attachments", filename: "c.csv", contentType: "text/csv", content: "c"},
+			},
+			status: 200,
+			want:   `{"code":200,"msg":"","data":{"title":"notes","filename":"a.txt","content":"hello","attachments":2}}`,
+		},
+		{
+			name:   "missing file",
+			parts:  []part{{field: "title", content: "notes"}},
+			status: 400,
+		},
+		{
+			name:   "too large",
+			parts:  []part{{field: "doc", filename: "a.txt", contentType: "text/plain", content: strings.Repeat("x", 2048)}},
+			status: 413,
+		},
+		{
+			name:   "mime type",
+			parts:  []part{{field: "doc", filename: "a.png", contentType: "image/png", content: "png"}},
+			status: 415,
+		},
+		{
+			// 声明的类型符合，但内容是 PNG
+			name:   "sniffed type",
+			parts:  []part{{field: "doc", filename: "a.txt", contentType: "text/plain", content: "\x89PNG\r\n\x1a\n0000"}},
+			status: 415,
+		},
+		{
+			// 内容识别为 text/plain，声明的 text/csv 符合即可
+			name:   "csv",
+			parts:  []part{{field: "doc", filename: "a.csv", contentType: "text/csv", content: "id,name\n1,gee\n"}},
+			status: 200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, body := multipartBody(tt.parts...)
+			req := httptest.NewRequest(http.MethodPost, "/upload", body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.status, w.Body)
+			}
+			if tt.want != "" && w.Body.String() != tt.want {
+				t.Errorf("body = %s, want %s", w.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestUploadCleanup(t *testing.T) {
+	// 超过 32MB 的文件保存在临时目录中
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := handle.RegisterObject(r, Uploads{}); err != nil {
+		t.Fatal(err)
+	}
+
+	contentType, body := multipartBody(part{field: "file", filename: "big.bin", contentType: "application/octet-stream", content: strings.Repeat("x", 33<<20)})
+	req := httptest.NewRequest(http.MethodPost, "/big", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"tempFiles":1`) {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("temp files left after the handler returned: %v", entries)
+	}
+}
+
+func TestUploadOpenAPI(t *testing.T) {
+	rs, err := handle.ObjectRoutes(Uploads{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := handle.OpenAPI(handle.OpenAPIInfo{}, rs)
+	media := doc.Paths["/upload"]["post"].RequestBody.Content["multipart/form-data"]
+	if media == nil {
+		t.Fatal("no multipart/form-data request body")
+	}
+	if doc := media.Schema.Properties["doc"]; doc == nil || doc.Format != "binary" {
+		t.Errorf("doc = %+v", doc)
+	}
+	if attachments := media.Schema.Properties["attachments"]; attachments == nil || attachments.Items.Format != "binary" {
+		t.Errorf("attachments = %+v", attachments)
+	}
+}
+
+type (
+	ValueUploadReq struct {
+		Doc  handle.Upload   `form:"doc"`
+		Docs []handle.Upload `form:"docs"`
+	}
+	ValueUploadRes struct {
+		Head string   `json:"head"`
+		Docs []string `json:"docs"`
+	}
+)
+
+func TestUploadValue(t *testing.T) {
+	var saved handle.Upload
+	f := handle.NewReqResFunc(func(ctx context.Context, req ValueUploadReq) (*ValueUploadRes, error) {
+		head := make([]byte, 2)
+		if _, err := io.ReadFull(&req.Doc, head); err != nil {
+			return nil, err
+		}
+		saved = req.Doc
+		res := &ValueUploadRes{Head: string(head)}
+		for i := range req.Docs {
+			content, err := io.ReadAll(&req.Docs[i])
+			if err != nil {
+				return nil, err
+			}
+			res.Docs = append(res.Docs, req.Docs[i].Filename+":"+string(content))
+		}
+		return res, nil
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/upload", f.Handler())
+
+	contentType, body := multipartBody(
+		part{field: "doc", filename: "a.txt", contentType: "text/plain", content: "hello"},
+		part{field: "docs", filename: "b.txt", contentType: "text/plain", content: "bb"},
+		part{field: "docs", filename: "c.txt", contentType: "text/plain", content: "cc"},
+	)
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"head":"he","docs":["b.txt:bb","c.txt:cc"]`) {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body)
+	}
+	// 值类型的 Upload 被复制后，处理函数打开的文件仍然在返回后关闭，再次读取时重新打开
+	if rest, err := io.ReadAll(&saved); err != nil || string(rest) != "hello" {
+		t.Errorf("read after the handler returned = %q, %v; want the file to be closed and reopened", rest, err)
+	}
+}
+
+type (
+	LimitReq struct {
+		meta  struct{}       `method:"POST" path:"/limit" limit:"1KB" mimes:"text/plain"`
+		Title string         `form:"title"`
+		Doc   *handle.Upload `form:"doc"`
+	}
+	LimitRes struct{}
+)
+
+type Limits struct{}
+
+func (Limits) PostLimit(ctx context.Context, req *LimitReq) (*LimitRes, error) {
+	return &LimitRes{}, nil
+}
+
+func TestUploadMeta(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fn := Limits{}.PostLimit
+	for name, register := range map[string]func(r *gin.Engine) error{
+		"RegisterObject": func(r *gin.Engine) error { return handle.RegisterObject(r, Limits{}) },
+		"RegisterByName": func(r *gin.Engine) error { return handle.RegisterByName(r, Limits{}, handle.NameOption{}) },
+		"ReqResFunc": func(r *gin.Engine) error {
+			r.POST("/limit", handle.NewReqResFunc(fn).Handler())
+			return nil
+		},
+		"Typed": func(r *gin.Engine) error {
+			r.POST("/limit", handle.Typed(fn).Handler())
+			return nil
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := gin.New()
+			if err := register(r); err != nil {
+				t.Fatal(err)
+			}
+			// meta 标签的 limit 与 mimes 不依赖注册方式
+			pngType, png := multipartBody(part{field: "doc", filename: "a.png", contentType: "image/png", content: "png"})
+			for _, tt := range []struct {
+				contentType, body string
+				want              int
+			}{
+				{"application/x-www-form-urlencoded", "title=ok", http.StatusOK},
+				{"application/x-www-form-urlencoded", "title=" + strings.Repeat("a", 2000), http.StatusRequestEntityTooLarge},
+				{pngType, png.String(), http.StatusUnsupportedMediaType},
+			} {
+				req := httptest.NewRequest(http.MethodPost, "/limit", strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", tt.contentType)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				if w.Code != tt.want {
+					t.Errorf("%s: status = %d, want %d, body = %s", tt.contentType, w.Code, tt.want, w.Body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,455 @@
+package handle
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError 字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`   // 字段名，取自 json、form、uri 标签，嵌套字段如 items[0].name
+	Rule    string `json:"rule"`    // 未通过的规则，如 required
+	Message string `json:"message"` // 错误消息
+	Param   string `json:"-"`       // 规则参数，如 min=3 中的 3
+
+	key string // 消息目录 Catalog 中的 key，如 min、min.length
+}
+
+// ValidationErrors 请求参数的校验错误，作为 Error.Details 返回给客户端
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate 根据 validate 标签校验结构体，point 为结构体指针，校验失败时返回 ValidationErrors
+//
+// 支持的规则，多个规则以逗号分隔：
+//
+//	required        必填，零值视为缺失
+//	omitempty       零值时跳过其他规则，nil 指针默认跳过
+//	min=3、max=10    数字比较大小，字符串、切片、map 比较长度
+//	len=6           字符串、切片、map 的长度
+//	enum=a|b|c      取值范围
+//	eqfield=Field   与同一结构体中的字段相等，类似的还有 nefield、gtfield、ltfield
+//	regex=^[0-9]+$  正则表达式，会读取标签剩余的全部内容，因此必须写在最后
+//
+// 每个字段只返回第一个未通过的规则，嵌套的结构体、结构体切片会递归校验
+func Validate(point any) error {
+	v := reflect.ValueOf(point)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs ValidationErrors
+	if err := validateStruct(v, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validationError 将 ValidationErrors 包装为 *Error
+func validationError(errs ValidationErrors) *Error {
+	e := NewError(CodeBadRequest, http.StatusBadRequest, "validation failed").WithDetails(errs).Wrap(errs)
+	e.Key = "validation"
+	return e
+}
+
+type rule struct {
+	name  string
+	param string
+	re    *regexp.Regexp // regex 规则预编译的正则表达式
+}
+
+type fieldRules struct {
+	index     int
+	name      string // 返回给客户端的字段名
+	anonymous bool   // 匿名字段，嵌套校验时不添加字段名前缀
+	rules     []rule
+}
+
+var rulesCache sync.Map // map[reflect.Type][]fieldRules
+
+func validateStruct(v reflect.Value, prefix string, errs *ValidationErrors) error {
+	frs, err := structRules(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, fr := range frs {
+		field := v.Field(fr.index)
+		name := prefix + fr.name
+
+		if err := validateField(v, field, name, fr.rules, errs); err != nil {
+			return err
+		}
+
+		if fr.anonymous {
+			name = strings.TrimSuffix(prefix, ".")
+		}
+		if err := validateNested(field, name, errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNested 递归校验嵌套的结构体与结构体切片
+func validateNested(field reflect.Value, name string, errs *ValidationErrors) error {
+	for field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			return nil
+		}
+		field = field.Elem()
+	}
+
+	switch field.Kind() {
+	case reflect.Struct:
+		if name != "" {
+			name += "."
+		}
+		return validateStruct(field, name, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < field.Len(); i++ {
+			elem := field.Index(i)
+			for elem.Kind() == reflect.Pointer && !elem.IsNil() {
+				elem = elem.Elem()
+			}
+			if elem.Kind() != reflect.Struct {
+				continue // nil 指针或非结构体元素，继续校验后面的元素
+			}
+			if err := validateStruct(elem, fmt.Sprintf("%s[%d].", name, i), errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateField 按顺序校验字段的规则，每个字段只返回第一个未通过的规则
+func validateField(parent, field reflect.Value, name string, rules []rule, errs *ValidationErrors) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	if field.IsZero() {
+		if r, ok := findRule(rules, "required"); ok {
+			*errs = append(*errs, newFieldError(name, r, field))
+			return nil
+		}
+		if _, ok := findRule(rules, "omitempty"); ok || field.Kind() == reflect.Pointer {
+			return nil
+		}
+	}
+
+	for field.Kind() == reflect.Pointer {
+		field = field.Elem()
+	}
+
+	for _, r := range rules {
+		ok, err := check(parent, field, r)
+		if err != nil {
+			return fmt.Errorf("validate %s: %w", name, err)
+		}
+		if !ok {
+			*errs = append(*errs, newFieldError(name, r, field))
+			return nil
+		}
+	}
+	return nil
+}
+
+func findRule(rules []rule, name string) (rule, bool) {
+	for _, r := range rules {
+		if r.name == name {
+			return r, true
+		}
+	}
+	return rule{}, false
+}
+
+// check 校验单个规则
+func check(parent, field reflect.Value, r rule) (bool, error) {
+	switch r.name {
+	case "required", "omitempty":
+		return true, nil
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(r.param, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid param of rule %s: %q", r.name, r.param)
+		}
+		n, ok := size(field)
+		if !ok {
+			return false, fmt.Errorf("rule %s is not supported by %s", r.name, field.Type())
+		}
+		switch r.name {
+		case "min":
+			return n >= limit, nil
+		case "max":
+			return n <= limit, nil
+		}
+		return n == limit, nil
+	case "enum":
+		s := fmt.Sprint(field.Interface())
+		for _, option := range strings.Split(r.param, "|") {
+			if s == option {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "regex":
+		if field.Kind() != reflect.String {
+			return false, fmt.Errorf("rule regex is not supported by %s", field.Type())
+		}
+		return r.re.MatchString(field.String()), nil
+	case "eqfield", "nefield", "gtfield", "ltfield":
+		other := parent.FieldByName(r.param)
+		if !other.IsValid() {
+			return false, fmt.Errorf("field %s of rule %s is not found", r.param, r.name)
+		}
+		for other.Kind() == reflect.Pointer && !other.IsNil() {
+			other = other.Elem()
+		}
+		return compare(r.name, field, other)
+	}
+	return false, fmt.Errorf("unknown rule %q", r.name)
+}
+
+// size 数字返回值本身，字符串、切片、map 返回长度
+func size(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	}
+	return 0, false
+}
+
+func compare(name string, a, b reflect.Value) (bool, error) {
+	switch name {
+	case "eqfield":
+		return a.Type() == b.Type() && a.Comparable() && a.Equal(b), nil
+	case "nefield":
+		return !(a.Type() == b.Type() && a.Comparable() && a.Equal(b)), nil
+	}
+
+	if a.Kind() == reflect.String && b.Kind() == reflect.String {
+		if name == "gtfield" {
+			return a.String() > b.String(), nil
+		}
+		return a.String() < b.String(), nil
+	}
+
+	x, ok1 := size(a)
+	y, ok2 := size(b)
+	if !ok1 || !ok2 {
+		return false, fmt.Errorf("rule %s is not supported by %s and %s", name, a.Type(), b.Type())
+	}
+	if name == "gtfield" {
+		return x > y, nil
+	}
+	return x < y, nil
+}
+
+// structRules 解析并缓存结构体的 validate 标签
+func structRules(t reflect.Type) ([]fieldRules, error) {
+	if cached, ok := rulesCache.Load(t); ok {
+		return cached.([]fieldRules), nil
+	}
+
+	var frs []fieldRules
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		rules, err := parseRules(field.Tag.Get("validate"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid validate tag of %s.%s: %w", t, field.Name, err)
+		}
+		frs = append(frs, fieldRules{index: i, name: fieldName(field), anonymous: field.Anonymous, rules: rules})
+	}
+
+	rulesCache.Store(t, frs)
+	return frs, nil
+}
+
+// checkRules 在构造处理函数时检查请求参数及其嵌套结构体的 validate 标签：规则未知、参数或正则表达式不合法、
+// 规则不支持字段的类型、引用的字段不存在时返回错误，而不是在每次请求时返回 500
+func checkRules(t reflect.Type) error {
+	return checkStructRules(t, map[reflect.Type]bool{})
+}
+
+func checkStructRules(t reflect.Type, seen map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	frs, err := structRules(t)
+	if err != nil {
+		return err
+	}
+	for _, fr := range frs {
+		field := t.Field(fr.index)
+		for _, r := range fr.rules {
+			if err := checkRule(t, field.Type, r); err != nil {
+				return fmt.Errorf("invalid validate tag of %s.%s: %w", t, field.Name, err)
+			}
+		}
+		if err := checkStructRules(field.Type, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRule 检查规则能否用于类型为 ft 的字段，与 check 在请求时返回的错误对应
+func checkRule(parent, ft reflect.Type, r rule) error {
+	for ft.Kind() == reflect.Pointer {
+		ft = ft.Elem()
+	}
+	switch r.name {
+	case "min", "max", "len":
+		if _, err := strconv.ParseFloat(r.param, 64); err != nil {
+			return fmt.Errorf("invalid param of rule %s: %q", r.name, r.param)
+		}
+		if _, ok := size(reflect.Zero(ft)); !ok {
+			return fmt.Errorf("rule %s is not supported by %s", r.name, ft)
+		}
+	case "regex":
+		if ft.Kind() != reflect.String {
+			return fmt.Errorf("rule regex is not supported by %s", ft)
+		}
+	case "eqfield", "nefield", "gtfield", "ltfield":
+		if _, ok := parent.FieldByName(r.param); !ok {
+			return fmt.Errorf("field %s of rule %s is not found", r.param, r.name)
+		}
+	}
+	return nil
+}
+
+func parseRules(tag string) ([]rule, error) {
+	var rules []rule
+	for tag != "" {
+		var item string
+		if strings.HasPrefix(tag, "regex=") {
+			item, tag = tag, "" // regex 读取剩余的全部内容
+		} else {
+			item, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, param, _ := strings.Cut(strings.TrimSpace(item), "=")
+		r := rule{name: name, param: param}
+		switch name {
+		case "":
+			continue
+		case "regex":
+			re, err := regexp.Compile(param)
+			if err != nil {
+				return nil, err
+			}
+			r.re = re
+		case "min", "max", "len", "enum", "eqfield", "nefield", "gtfield", "ltfield":
+			if param == "" {
+				return nil, fmt.Errorf("rule %s requires a param", name)
+			}
+		case "required", "omitempty":
+		default:
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// fieldName 返回给客户端的字段名，声明了 in 标签时为参数名，否则依次取自 json、form、uri 标签，缺省为字段名
+func fieldName(field reflect.StructField) string {
+	if in, ok := field.Tag.Lookup("in"); ok {
+		if _, ok := sourceTags[in]; ok {
+			return sourceName(field, in)
+		}
+	}
+	for _, key := range []string{"json", "form", "uri"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func newFieldError(name string, r rule, field reflect.Value) FieldError {
+	key, msg := ruleMessage(r, field)
+	return FieldError{Field: name, Rule: r.name, Param: r.param, Message: name + " " + msg, key: key}
+}
+
+// ruleMessage 返回规则在消息目录中的 key 与默认的错误消息
+func ruleMessage(r rule, field reflect.Value) (key string, msg string) {
+	numeric := false
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		numeric = true
+	}
+
+	switch r.name {
+	case "required":
+		return r.name, "is required"
+	case "min":
+		if numeric {
+			return r.name, "must be at least " + r.param
+		}
+		return "min.length", "length must be at least " + r.param
+	case "max":
+		if numeric {
+			return r.name, "must be at most " + r.param
+		}
+		return "max.length", "length must be at most " + r.param
+	case "len":
+		return r.name, "length must be " + r.param
+	case "enum":
+		return r.name, "must be one of " + strings.ReplaceAll(r.param, "|", ", ")
+	case "regex":
+		return r.name, "must match " + r.param
+	case "eqfield":
+		return r.name, "must be equal to " + r.param
+	case "nefield":
+		return r.name, "must not be equal to " + r.param
+	case "gtfield":
+		return r.name, "must be greater than " + r.param
+	case "ltfield":
+		return r.name, "must be less than " + r.param
+	}
+	return r.name, "is invalid"
+}
@@ -0,0 +1,133 @@
+package handle_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gee/web/day10/handle"
+)
+
+type (
+	SignUpReq struct {
+		Name     string  `json:"name" validate:"required,min=2,max=8"`
+		Code     string  `json:"code" validate:"len=6,regex=^[0-9]{3,6}$"`
+		Role     string  `json:"role" validate:"omitempty,enum=admin|member"`
+		Age      int     `json:"age" validate:"min=18"`
+		Password string  `json:"password" validate:"required"`
+		Confirm  string  `json:"confirm" validate:"eqfield=Password"`
+		Start    int     `json:"start"`
+		End      int     `json:"end" validate:"gtfield=Start"`
+		Invite   *string `json:"invite" validate:"min=4"`
+		Tags     []Tag   `json:"tags" validate:"max=2"`
+	}
+	Tag struct {
+		Name string `json:"name" validate:"required"`
+	}
+	SignUpRes struct{}
+)
+
+func SignUp(ctx context.Context, req *SignUpReq) (res *SignUpRes, err error) {
+	return &SignUpRes{}, nil
+}
+
+func TestValidate(t *testing.T) {
+	h := handle.Typed(SignUp).Handler()
+
+	status, resp := serve(t, http.MethodPost, "/sign-up", "/sign-up", h,
+		`{"name":"a","code":"12345a","role":"root","age":16,"password":"x","confirm":"y","start":3,"end":1,"tags":[{"name":"ok"},{}]}`)
+	if status != http.StatusBadRequest || resp.Code != handle.CodeBadRequest || resp.Msg != "validation failed" {
+		t.Fatalf("got status = %d, resp = %+v", status, resp)
+	}
+
+	var got []handle.FieldError
+	for _, item := range resp.Data.([]any) {
+		m := item.(map[string]any)
+		got = append(got, handle.FieldError{Field: m["field"].(string), Rule: m["rule"].(string), Message: m["message"].(string)})
+	}
+	want := []handle.FieldError{
+		{Field: "name", Rule: "min", Message: "name length must be at least 2"},
+		{Field: "code", Rule: "regex", Message: `code must match ^[0-9]{3,6}$`},
+		{Field: "role", Rule: "enum", Message: "role must be one of admin, member"},
+		{Field: "age", Rule: "min", Message: "age must be at least 18"},
+		{Field: "confirm", Rule: "eqfield", Message: "confirm must be equal to Password"},
+		{Field: "end", Rule: "gtfield", Message: "end must be greater than Start"},
+		{Field: "tags[1].name", Rule: "required", Message: "tags[1].name is required"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+
+	status, resp = serve(t, http.MethodPost, "/sign-up", "/sign-up", h,
+		`{"name":"gee","code":"123456","age":18,"password":"x","confirm":"x","end":1,"invite":"abcd"}`)
+	if status != http.StatusOK || resp.Code != handle.CodeOK {
+		t.Errorf("got status = %d, resp = %+v", status, resp)
+	}
+}
+
+type (
+	UnknownRuleReq struct {
+		Name string `validate:"bogus"`
+	}
+	BadRegexItem struct {
+		Code string `validate:"regex=[a-"`
+	}
+	BadRegexReq struct {
+		Items []BadRegexItem
+	}
+	BadParamReq struct {
+		Age int `validate:"min=x"`
+	}
+	BadRuleTypeReq struct {
+		Age int `validate:"regex=^1$"`
+	}
+	BadRuleFieldReq struct {
+		Password string `validate:"eqfield=Confirm"`
+	}
+)
+
+func TestValidateInvalidTag(t *testing.T) {
+	var req struct {
+		Name string `validate:"unknown"`
+	}
+	if err := handle.Validate(&req); err == nil {
+		t.Error("want error for unknown rule")
+	}
+
+	// 处理函数构造时就检查 validate 标签，包括嵌套的结构体，而不是在每次请求时返回 500
+	tests := []struct {
+		name string
+		fn   any
+		want string
+	}{
+		{"unknown", func(ctx context.Context, req *UnknownRuleReq) error { return nil }, `unknown rule "bogus"`},
+		{"regex", func(ctx context.Context, req *BadRegexReq) error { return nil }, "missing closing ]"},
+		{"param", func(ctx context.Context, req *BadParamReq) error { return nil }, `invalid param of rule min: "x"`},
+		{"type", func(ctx context.Context, req *BadRuleTypeReq) error { return nil }, "rule regex is not supported by int"},
+		{"field", func(ctx context.Context, req *BadRuleFieldReq) error { return nil }, "field Confirm of rule eqfield is not found"},
+	}
+	for _, tt := range tests {
+		_, err := handle.TryNewReqResFunc(tt.fn)
+		var se *handle.SignatureError
+		if !errors.As(err, &se) || se.Rule != handle.RuleValidate || !strings.Contains(se.Msg, tt.want) {
+			t.Errorf("%s: got %v, want rule %s with %q", tt.name, err, handle.RuleValidate, tt.want)
+		}
+	}
+}
+
+func TestValidateNilElement(t *testing.T) {
+	type Item struct {
+		Name string `json:"name" validate:"required"`
+	}
+	req := struct {
+		Items []*Item `json:"items"`
+	}{Items: []*Item{nil, {}}}
+
+	var errs handle.ValidationErrors
+	if err := handle.Validate(&req); !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != "items[1].name" {
+		t.Errorf("got %v, want items[1].name is required", err)
+	}
+}
@@ -0,0 +1,362 @@
+package handle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Message WebSocket 中收发的消息，以 JSON 文本帧传输：
+//
+//	→ {"type":"join","id":"1","data":{"name":"gee"}}
+//	← {"type":"join","id":"1","data":{"code":200,"msg":"","data":{...}}}
+//
+// 回复与推送的 data 与 HTTP 响应一样经过 Envelope 包装
+type Message struct {
+	Type string          `json:"type"`           // 消息类型，用于分发到处理函数
+	Id   string          `json:"id,omitempty"`   // 消息 id，回复时原样返回，用于客户端匹配请求与回复
+	Data json.RawMessage `json:"data,omitempty"` // 消息数据
+}
+
+// outbound 发送的消息，Data 为 Envelope 包装后的响应体
+type outbound struct {
+	Type string `json:"type"`
+	Id   string `json:"id,omitempty"`
+	Data any    `json:"data"`
+}
+
+// WebSocket 按消息类型分发到处理函数的 WebSocket 端点，用法：
+//
+//	ws := handle.NewWebSocket()
+//	_ = ws.On("join", controller.Team.Join) // func(ctx context.Context, req *TeamJoinReq) (*TeamJoinRes, error)
+//	ws.OnConnect(func(conn *handle.Conn) error { go watch(conn); return nil })
+//	r.GET("/team/:id/ws", ws.Handler())
+//
+// 请求参数从消息的 data 中以 JSON 反序列化，in 标签与 uri 标签读取自升级连接时的 HTTP 请求，
+// 之后与 HTTP 请求一样设置默认值并校验。同一个连接的消息按顺序处理；
+// 处理函数的 ctx 在连接关闭时取消，可以通过 Push 向客户端推送消息
+type WebSocket struct {
+	o        *options
+	upgrader websocket.Upgrader
+	handlers map[string]*ReqResFunc
+
+	onConnect func(conn *Conn) error
+	onClose   func(conn *Conn)
+
+	mu    sync.Mutex
+	conns map[*Conn]struct{}
+}
+
+// NewWebSocket 返回 WebSocket，opts 用于配置回复的 Envelope、命名规范与心跳间隔等
+func NewWebSocket(opts ...Option) *WebSocket {
+	return &WebSocket{
+		o:        newOptions(opts),
+		handlers: map[string]*ReqResFunc{},
+		conns:    map[*Conn]struct{}{},
+	}
+}
+
+// WithPingInterval 设置 WebSocket 发送 ping 的间隔，默认为 30s，
+// 两个间隔内没有收到客户端的任何消息（包括 pong）时关闭连接；小于等于 0 时不发送 ping，也不关闭空闲的连接
+func WithPingInterval(interval time.Duration) Option {
+	return func(o *options) { o.pingInterval = interval }
+}
+
+// WithCheckOrigin 设置升级连接时校验 Origin 的函数，默认要求 Origin 与 Host 一致
+func WithCheckOrigin(check func(r *http.Request) bool) Option {
+	return func(o *options) { o.checkOrigin = check }
+}
+
+// On 注册消息类型 messageType 的处理函数，格式与 NewReqResFunc 相同，且第一个参数必须是 context.Context
+func (ws *WebSocket) On(messageType string, fn any) error {
+	f, errs := newReqResFunc(reflect.ValueOf(fn), messageType, ws.o)
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if f.ctx != reflect.TypeOf((*context.Context)(nil)).Elem() {
+		return &SignatureError{Method: messageType, Type: f.ctx, Rule: RuleContext, Msg: "the first parameter of a message handler must be context.Context"}
+	}
+	if _, ok := ws.handlers[messageType]; ok {
+		return fmt.Errorf("message type %q is already registered", messageType)
+	}
+	ws.handlers[messageType] = f
+	return nil
+}
+
+// OnConnect 设置连接建立后的回调，返回错误时关闭连接，可以在其中启动推送消息的 goroutine
+func (ws *WebSocket) OnConnect(fn func(conn *Conn) error) { ws.onConnect = fn }
+
+// OnClose 设置连接关闭后的回调
+func (ws *WebSocket) OnClose(fn func(conn *Conn)) { ws.onClose = fn }
+
+// Shutdown 向所有连接发送关闭帧，并等待客户端确认或 ctx 结束
+func (ws *WebSocket) Shutdown(ctx context.Context) error {
+	ws.mu.Lock()
+	conns := make([]*Conn, 0, len(ws.conns))
+	for conn := range ws.conns {
+		conns = append(conns, conn)
+	}
+	ws.mu.Unlock()
+
+	for _, conn := range conns {
+		go func(conn *Conn) { _ = conn.Close() }(conn)
+	}
+	for _, conn := range conns {
+		select {
+		case <-conn.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Handler 返回升级连接的 gin.HandlerFunc，连接关闭后返回
+func (ws *WebSocket) Handler() gin.HandlerFunc {
+	ws.upgrader.CheckOrigin = ws.o.checkOrigin
+
+	return func(c *gin.Context) {
+		wsConn, err := ws.upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			_ = c.Error(err) // Upgrade 已经返回了 HTTP 错误
+			return
+		}
+
+		conn := newConn(c, ws.o, wsConn)
+		ws.mu.Lock()
+		ws.conns[conn] = struct{}{}
+		ws.mu.Unlock()
+		defer func() {
+			ws.mu.Lock()
+			delete(ws.conns, conn)
+			ws.mu.Unlock()
+		}()
+
+		go conn.writeLoop()
+		defer conn.shutdown()
+
+		if ws.onConnect != nil {
+			if err := ws.onConnect(conn); err != nil {
+				close(conn.done) // 不会再读取消息，关闭时无需等待
+				conn.closeWith(websocket.ClosePolicyViolation, err.Error())
+				return
+			}
+		}
+		if ws.onClose != nil {
+			defer ws.onClose(conn)
+		}
+
+		conn.readLoop(ws.dispatch)
+	}
+}
+
+// dispatch 处理一条消息，返回回复
+func (ws *WebSocket) dispatch(conn *Conn, data []byte) outbound {
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return conn.failure("error", "", badRequest(err))
+	}
+	f, ok := ws.handlers[msg.Type]
+	if !ok {
+		return conn.failure(msg.Type, msg.Id, badRequest(fmt.Errorf("unknown message type %q", msg.Type)))
+	}
+
+	res, err := f.Call(conn.ctx, func(point any) error { return conn.decode(msg.Data, point) })
+	if err != nil {
+		return conn.failure(msg.Type, msg.Id, err)
+	}
+	_, body := conn.o.envelope.Success(conn.c, res)
+	return outbound{Type: msg.Type, Id: msg.Id, Data: body}
+}
+
+// Conn 一个 WebSocket 连接
+type Conn struct {
+	c      *gin.Context // 升级连接时 *gin.Context 的副本，供 decode 与 Envelope 读取请求，Handler 返回后仍然有效
+	o      *options
+	ws     *websocket.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	out       chan outbound
+	done      chan struct{} // 读取结束后关闭
+	closeOnce sync.Once
+}
+
+type connKey struct{}
+
+// newConn 保存 c 的副本：gin 在 Handler 返回后会复用 c，而 Push 可能在任意 goroutine 中调用
+func newConn(c *gin.Context, o *options, ws *websocket.Conn) *Conn {
+	c = c.Copy()
+	conn := &Conn{
+		c:    c,
+		o:    o,
+		ws:   ws,
+		out:  make(chan outbound, 16),
+		done: make(chan struct{}),
+	}
+	conn.ctx, conn.cancel = context.WithCancel(context.WithValue(requestValues(c), connKey{}, conn))
+	return conn
+}
+
+// ConnFrom 返回 ctx 所属的 WebSocket 连接，不是消息处理函数的 ctx 时返回 nil
+func ConnFrom(ctx context.Context) *Conn {
+	conn, _ := ctx.Value(connKey{}).(*Conn)
+	return conn
+}
+
+// Push 向 ctx 所属的连接推送消息，服务层可以通过它推送而不依赖 gin
+func Push(ctx context.Context, messageType string, data any) error {
+	conn := ConnFrom(ctx)
+	if conn == nil {
+		return errors.New("context does not belong to a websocket connection")
+	}
+	return conn.Push(messageType, data)
+}
+
+// Context 返回连接的 context，连接关闭时取消
+func (conn *Conn) Context() context.Context { return conn.ctx }
+
+// Request 返回升级连接时的 HTTP 请求
+func (conn *Conn) Request() *http.Request { return conn.c.Request }
+
+// Push 推送消息，data 经过 Envelope 包装；连接已关闭时返回 net.ErrClosed
+func (conn *Conn) Push(messageType string, data any) error {
+	_, body := conn.o.envelope.Success(conn.c, data)
+	return conn.send(outbound{Type: messageType, Data: body})
+}
+
+// Close 发送关闭帧，等待客户端确认后关闭连接
+func (conn *Conn) Close() error {
+	conn.closeWith(websocket.CloseNormalClosure, "")
+	return nil
+}
+
+const (
+	writeTimeout = 10 * time.Second
+	closeTimeout = time.Second // 发送关闭帧后等待客户端确认的时间
+	readLimit    = 1 << 20     // 单条消息的最大字节数
+)
+
+func (conn *Conn) send(m outbound) error {
+	if conn.ctx.Err() != nil {
+		return net.ErrClosed // out 有缓冲，关闭后 select 仍然可能选中发送
+	}
+	select {
+	case conn.out <- m:
+		return nil
+	case <-conn.ctx.Done():
+		return net.ErrClosed
+	}
+}
+
+// closeWith 发送关闭帧，读取结束或超时后关闭底层连接
+func (conn *Conn) closeWith(code int, text string) {
+	conn.closeOnce.Do(func() {
+		msg := websocket.FormatCloseMessage(code, text)
+		_ = conn.ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeTimeout))
+		select {
+		case <-conn.done:
+		case <-time.After(closeTimeout):
+		}
+		conn.shutdown()
+	})
+}
+
+// shutdown 取消 ctx 并关闭底层连接
+func (conn *Conn) shutdown() {
+	conn.cancel()
+	_ = conn.ws.Close()
+}
+
+// readLoop 依次读取并处理消息，直到连接关闭
+func (conn *Conn) readLoop(dispatch func(conn *Conn, data []byte) outbound) {
+	defer close(conn.done)
+
+	conn.ws.SetReadLimit(readLimit)
+	_ = conn.extendReadDeadline()
+	conn.ws.SetPongHandler(func(string) error { return conn.extendReadDeadline() })
+
+	for {
+		kind, data, err := conn.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.extendReadDeadline()
+		if kind != websocket.TextMessage {
+			continue
+		}
+		if err := conn.send(dispatch(conn, data)); err != nil {
+			return
+		}
+	}
+}
+
+// extendReadDeadline 收到消息后延长读取的截止时间，不发送 ping 时不设置
+func (conn *Conn) extendReadDeadline() error {
+	if conn.o.pingInterval <= 0 {
+		return nil
+	}
+	return conn.ws.SetReadDeadline(time.Now().Add(2 * conn.o.pingInterval))
+}
+
+// writeLoop 发送消息与 ping，gorilla/websocket 同一时间只允许一个写入者
+func (conn *Conn) writeLoop() {
+	var ping <-chan time.Time // 为 nil 时不发送 ping
+	if conn.o.pingInterval > 0 {
+		ticker := time.NewTicker(conn.o.pingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+
+	for {
+		select {
+		case m := <-conn.out:
+			_ = conn.ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.ws.WriteJSON(m); err != nil {
+				conn.shutdown()
+				return
+			}
+		case <-ping:
+			if err := conn.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				conn.shutdown()
+				return
+			}
+		case <-conn.ctx.Done():
+			return
+		}
+	}
+}
+
+// decode 反序列化消息数据，与 HTTP 请求的 decode 保持一致
+func (conn *Conn) decode(data json.RawMessage, point any) error {
+	c := conn.c
+	if len(c.Params) > 0 {
+		if err := c.ShouldBindUri(point); err != nil {
+			return badRequest(err)
+		}
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, point); err != nil {
+			return badRequest(err)
+		}
+	}
+	if err := bindSources(c, point, true); err != nil {
+		return err
+	}
+	return validateRequest(point)
+}
+
+// failure 以 Envelope.Failure 包装错误
+func (conn *Conn) failure(messageType, id string, err error) outbound {
+	_, body := conn.o.failure(conn.c, err)
+	return outbound{Type: messageType, Id: id, Data: body}
+}
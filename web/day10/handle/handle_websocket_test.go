@@ -0,0 +1,242 @@
+package handle_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gee/web/day10/handle"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+type (
+	JoinReq struct {
+		TeamId int    `uri:"id"`
+		Name   string `json:"name" validate:"required"`
+	}
+	JoinRes struct {
+		TeamId int    `json:"teamId"`
+		Name   string `json:"name"`
+	}
+)
+
+type reply struct {
+	Type string          `json:"type"`
+	Id   string          `json:"id"`
+	Data handle.Response `json:"data"`
+}
+
+func TestWebSocket(t *testing.T) {
+	var (
+		connCtx = make(chan context.Context, 1)
+		closed  = make(chan struct{})
+	)
+
+	ws := handle.NewWebSocket(handle.WithPingInterval(20 * time.Millisecond))
+	if err := ws.On("join", func(ctx context.Context, req *JoinReq) (*JoinRes, error) {
+		connCtx <- ctx
+		// 服务层通过 ctx 推送消息，不依赖 gin
+		if err := handle.Push(ctx, "joined", req.Name); err != nil {
+			return nil, err
+		}
+		return &JoinRes{TeamId: req.TeamId, Name: req.Name}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.On("leave", func(ctx context.Context, req *JoinReq) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	ws.OnConnect(func(conn *handle.Conn) error { return conn.Push("welcome", conn.Request().URL.Path) })
+	ws.OnClose(func(conn *handle.Conn) { close(closed) })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/team/:id/ws", ws.Handler())
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/team/7/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	pinged := make(chan struct{}, 1)
+	client.SetPingHandler(func(data string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return client.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	read := func() reply {
+		t.Helper()
+		var m reply
+		if err := client.ReadJSON(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	if m := read(); m.Type != "welcome" || m.Data.Data != "/team/7/ws" {
+		t.Errorf("welcome = %+v", m)
+	}
+
+	_ = client.WriteJSON(handle.Message{Type: "join", Id: "1", Data: json.RawMessage(`{"name":"gee"}`)})
+	if m := read(); m.Type != "joined" || m.Data.Data != "gee" {
+		t.Errorf("push = %+v", m)
+	}
+	m := read()
+	data, _ := m.Data.Data.(map[string]any)
+	if m.Type != "join" || m.Id != "1" || m.Data.Code != handle.CodeOK || data["teamId"] != 7.0 || data["name"] != "gee" {
+		t.Errorf("reply = %+v", m)
+	}
+
+	_ = client.WriteJSON(handle.Message{Type: "leave", Id: "2", Data: json.RawMessage(`{"name":"gee"}`)})
+	if m := read(); m.Id != "2" || m.Data.Code != handle.CodeOK || m.Data.Data != nil {
+		t.Errorf("leave = %+v", m)
+	}
+
+	_ = client.WriteJSON(handle.Message{Type: "join", Id: "3", Data: json.RawMessage(`{}`)})
+	if m := read(); m.Id != "3" || m.Data.Code != handle.CodeBadRequest {
+		t.Errorf("invalid = %+v", m)
+	}
+
+	_ = client.WriteJSON(handle.Message{Type: "kick", Id: "4"})
+	if m := read(); m.Id != "4" || m.Data.Code != handle.CodeBadRequest || !strings.Contains(m.Data.Msg, "unknown message type") {
+		t.Errorf("unknown = %+v", m)
+	}
+
+	// ping 由读取消息的循环处理
+	go func() {
+		for {
+			if _, _, err := client.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	select {
+	case <-pinged:
+	case <-time.After(time.Second):
+		t.Error("no ping received")
+	}
+
+	// 优雅关闭：发送关闭帧，取消连接的 ctx，调用 OnClose
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := ws.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("OnClose was not called")
+	}
+	if err := (<-connCtx).Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("connection ctx.Err() = %v", err)
+	}
+}
+
+func TestWebSocketOn(t *testing.T) {
+	ws := handle.NewWebSocket()
+
+	err := ws.On("join", func(c *gin.Context, req *JoinReq) (*JoinRes, error) { return nil, nil })
+	var se *handle.SignatureError
+	if !errors.As(err, &se) || se.Rule != handle.RuleContext {
+		t.Errorf("On(*gin.Context) = %v", err)
+	}
+
+	if err := ws.On("join", func(ctx context.Context, req *JoinReq) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.On("join", func(ctx context.Context, req *JoinReq) error { return nil }); err == nil {
+		t.Error("duplicate On = nil")
+	}
+}
+
+func TestWebSocketNoPing(t *testing.T) {
+	ws := handle.NewWebSocket(handle.WithPingInterval(0))
+	if err := ws.On("echo", func(ctx context.Context, req *JoinReq) (*JoinRes, error) {
+		return &JoinRes{Name: req.Name}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/team/:id/ws", ws.Handler())
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/team/7/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	pinged := make(chan struct{}, 1)
+	client.SetPingHandler(func(string) error {
+		pinged <- struct{}{}
+		return nil
+	})
+
+	// 为 0 时不发送 ping，读取也没有截止时间，连接空闲一段时间后仍然可用
+	time.Sleep(50 * time.Millisecond)
+	_ = client.WriteJSON(handle.Message{Type: "echo", Id: "1", Data: json.RawMessage(`{"name":"gee"}`)})
+	var m reply
+	if err := client.ReadJSON(&m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Id != "1" || m.Data.Code != handle.CodeOK {
+		t.Errorf("reply = %+v", m)
+	}
+	select {
+	case <-pinged:
+		t.Error("ping received")
+	default:
+	}
+}
+
+func TestWebSocketConnAfterClose(t *testing.T) {
+	var (
+		conns  = make(chan *handle.Conn, 1)
+		closed = make(chan struct{})
+	)
+	ws := handle.NewWebSocket()
+	ws.OnConnect(func(conn *handle.Conn) error { conns <- conn; return nil })
+	ws.OnClose(func(conn *handle.Conn) { close(closed) })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/team/:id/ws", ws.Handler())
+	r.GET("/ping", func(c *gin.Context) { c.String(200, "pong") })
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/team/7/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := <-conns
+	_ = client.Close()
+	<-closed
+
+	// 升级连接的 *gin.Context 已经被 gin 复用，Conn 仍然返回升级连接时的请求
+	for i := 0; i < 10; i++ {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ping", nil))
+	}
+	if path := conn.Request().URL.Path; path != "/team/7/ws" {
+		t.Errorf("Request().URL.Path = %q", path)
+	}
+	if err := conn.Push("late", "gee"); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Push after close = %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"slices"
 
 	"gee/web/day10/db"
+	"gee/web/day10/trace"
 )
 
 type user struct{}
@@ -16,7 +17,8 @@ func (s *user) Get(ctx context.Context, req *UserGetReq) (res *UserGetRes, err e
 	// 查询数据
 	i := slices.IndexFunc(db.Users, func(row db.User) bool { return row.Id == req.Id })
 	if i == -1 { // 数据库未找到数据
-		return nil, fmt.Errorf("user not found: %d", req.Id)
+		trace.Logger(ctx).Info("user not found", "id", req.Id) // 日志携带 request_id
+		return nil, fmt.Errorf("user %w: %d", ErrNotFound, req.Id)
 	}
 
 	// 返回数据库内容
@@ -32,7 +34,7 @@ func (s *team) Get(ctx context.Context, req *TeamGetReq) (res *TeamGetRes, err e
 	// 查询数据
 	i := slices.IndexFunc(db.Teams, func(row db.Team) bool { return row.Id == req.Id })
 	if i == -1 { // 数据库未找到数据
-		return nil, fmt.Errorf("team not found: %d", req.Id)
+		return nil, fmt.Errorf("team %w: %d", ErrNotFound, req.Id)
 	}
 
 	// 返回数据库内容
@@ -0,0 +1,11 @@
+package service
+
+import "errors"
+
+// 业务哨兵错误，由 handle.RegisterError 映射为对应的业务代码
+var (
+	ErrNotFound  = errors.New("not found")      // 资源不存在
+	ErrConflict  = errors.New("conflict")       // 资源冲突
+	ErrForbidden = errors.New("forbidden")      // 无权访问
+	ErrInternal  = errors.New("internal error") // 服务内部错误
+)
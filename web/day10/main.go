@@ -1,20 +1,53 @@
 package main
 
 import (
+	"embed"
+	"net/http"
+
 	"gee/web/day10/handle"
 	"gee/web/day10/internal/controller"
+	"gee/web/day10/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
+//go:embed i18n
+var i18n embed.FS
+
 func main() {
+	// 注册 service 哨兵错误与业务代码的映射
+	handle.RegisterError(service.ErrNotFound, handle.CodeNotFound, http.StatusNotFound)
+	handle.RegisterError(service.ErrConflict, handle.CodeConflict, http.StatusConflict)
+	handle.RegisterError(service.ErrForbidden, handle.CodeForbidden, http.StatusForbidden)
+	handle.RegisterError(service.ErrInternal, handle.CodeInternal, http.StatusInternalServerError)
+
+	// 加载消息目录，根据 Accept-Language 返回中文或英文消息
+	if err := handle.LoadCatalogFS(i18n, "zh", "i18n/zh.yaml"); err != nil {
+		panic(err)
+	}
+
 	r := gin.Default()
+	r.Use(handle.RequestID()) // 读取或生成 X-Request-ID，服务层通过 trace 包读取
 
 	r.GET("/user/:id", handle.Handle(controller.User.Get))
 	r.GET("/user/:id/team", handle.Handle(controller.User.GetWithTeam))
 
 	r.GET("/team/:id", handle.Handle(controller.Team.Get))
-	r.GET("/team/:id/users", handle.Handle(handle.NewReqResFunc(controller.Team.GetUsers).DecodeFunc()))
+
+	// 通过 handle 注册的路由带有类型信息，可以生成 OpenAPI 文档
+	err := handle.RegisterRoutes(r, handle.Route{
+		Method:  http.MethodGet,
+		Path:    "/team/:id/users",
+		Summary: "查询团队成员",
+		Tags:    []string{"team"},
+		Func:    handle.NewReqResFunc(controller.Team.GetUsers),
+	})
+	if err != nil {
+		panic(err)
+	}
+	info := handle.OpenAPIInfo{Title: "gee", Version: "day10"}
+	handle.MountOpenAPI(r, "/openapi", info)
+	handle.MountExplorer(r, "/explorer", info)
 
 	r.Run()
 }
@@ -0,0 +1,53 @@
+// Package trace 在 context.Context 中传递请求 id，服务层通过它读取请求 id 与输出日志，不需要依赖 gin：
+//
+//	func (s *user) Get(ctx context.Context, req *UserGetReq) (*UserGetRes, error) {
+//		trace.Logger(ctx).Info("get user", "id", req.Id) // 输出 request_id=...
+//	}
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+)
+
+// Header 传递请求 id 的请求头与响应头
+const Header = "X-Request-ID"
+
+// Key 以字符串保存请求 id 的 key，用于只支持字符串 key 的 context，如 *gin.Context 的 Value 只读取 c.Set 写入的值
+const Key = "trace.request_id"
+
+type requestIDKey struct{}
+
+// WithRequestID 返回携带请求 id 的 ctx
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 返回 ctx 中的请求 id，没有时为空
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	id, _ := ctx.Value(Key).(string)
+	return id
+}
+
+// NewRequestID 生成 32 位十六进制的随机请求 id
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Logger 返回携带请求 id 的 slog.Default()，ctx 中没有请求 id 时原样返回
+func Logger(ctx context.Context) *slog.Logger {
+	if id := RequestID(ctx); id != "" {
+		return slog.Default().With("request_id", id)
+	}
+	return slog.Default()
+}
@@ -0,0 +1,33 @@
+package trace_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"gee/web/day10/trace"
+)
+
+func TestRequestID(t *testing.T) {
+	if id := trace.RequestID(context.Background()); id != "" {
+		t.Errorf("RequestID(Background) = %q, want empty", id)
+	}
+	if a, b := trace.NewRequestID(), trace.NewRequestID(); len(a) != 32 || a == b {
+		t.Errorf("NewRequestID() = %q, %q", a, b)
+	}
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	ctx := trace.WithRequestID(context.Background(), "req-1")
+	if id := trace.RequestID(ctx); id != "req-1" {
+		t.Errorf("RequestID = %q, want req-1", id)
+	}
+	trace.Logger(ctx).Info("hello")
+	if !strings.Contains(logs.String(), "request_id=req-1") {
+		t.Errorf("log %q does not contain the request id", logs.String())
+	}
+}